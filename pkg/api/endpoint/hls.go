@@ -0,0 +1,232 @@
+package endpoint
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/juanvallejo/streaming-server/pkg/socket/connection"
+	"github.com/juanvallejo/streaming-server/pkg/stream"
+)
+
+const HLS_ENDPOINT_PREFIX = "/hls"
+
+// hlsVariant describes a single #EXT-X-STREAM-INF variant entry parsed
+// from an HLS master playlist.
+type hlsVariant struct {
+	URL        string
+	Bandwidth  int
+	Resolution string
+	Codecs     string
+}
+
+// hlsUnsupportedCodecs lists codec substrings this server cannot forward
+// to clients for playback (e.g. legacy/DRM-gated audio codecs).
+var hlsUnsupportedCodecs = []string{"ec-3", "ac-3"}
+
+// HLSEndpoint implements ApiEndpoint. It fetches and parses HLS (.m3u8)
+// master playlists and returns the highest-bandwidth supported variant
+// as a normalized EndpointResponseItem.
+type HLSEndpoint struct {
+	*ApiEndpointSchema
+}
+
+// Handle accepts a "/hls/<url>" request pointing at a master playlist,
+// parses its variant streams, and returns the picked leading variant.
+func (e *HLSEndpoint) Handle(connHandler connection.ConnectionHandler, segments []string, w http.ResponseWriter, r *http.Request) {
+	segments = strings.Split(r.URL.String(), "/")
+	segments = segments[2:]
+
+	if len(segments) < 2 {
+		HandleEndpointError(fmt.Errorf("not enough arguments: /hls/url"), w)
+		return
+	}
+
+	playlistUrl := strings.Join(segments[1:], "/")
+	handleHLSPlaylistRequest(playlistUrl, w)
+}
+
+func handleHLSPlaylistRequest(playlistUrl string, w http.ResponseWriter) {
+	res, err := http.Get(playlistUrl)
+	if err != nil {
+		HandleEndpointError(err, w)
+		return
+	}
+	defer res.Body.Close()
+
+	variants, err := parseMasterPlaylist(res.Body, playlistUrl)
+	if err != nil {
+		HandleEndpointError(err, w)
+		return
+	}
+
+	if len(variants) == 0 {
+		HandleEndpointError(fmt.Errorf("error: no variant streams found in playlist %q", playlistUrl), w)
+		return
+	}
+
+	leading := pickLeadingVariant(variants)
+
+	item := &EndpointResponseItem{
+		Kind: stream.StreamKindHLS,
+		Url:  leading.URL,
+	}
+
+	resp := &SoundCloudEndpointResponse{
+		Items: []*SoundCloudItem{{EndpointResponseItem: item}},
+	}
+
+	respBytes, err := json.Marshal(resp)
+	if err != nil {
+		HandleEndpointError(err, w)
+		return
+	}
+
+	w.Write(respBytes)
+}
+
+// parseMasterPlaylist walks an HLS master playlist line-by-line,
+// pairing each #EXT-X-STREAM-INF tag with the variant URI that follows
+// it. Relative variant URIs are resolved against the playlist's own URL.
+func parseMasterPlaylist(r io.Reader, playlistUrl string) ([]hlsVariant, error) {
+	base := playlistUrl[:strings.LastIndex(playlistUrl, "/")+1]
+
+	variants := []hlsVariant{}
+	scanner := bufio.NewScanner(r)
+
+	var pending *hlsVariant
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 {
+			continue
+		}
+
+		if strings.HasPrefix(line, "#EXT-X-STREAM-INF:") {
+			v := parseStreamInfAttributes(strings.TrimPrefix(line, "#EXT-X-STREAM-INF:"))
+			pending = &v
+			continue
+		}
+
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		// a plain line following a #EXT-X-STREAM-INF tag is the variant URI
+		if pending != nil {
+			pending.URL = resolveHLSUrl(base, line)
+			variants = append(variants, *pending)
+			pending = nil
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return variants, nil
+}
+
+func resolveHLSUrl(base, uri string) string {
+	if strings.HasPrefix(uri, "http://") || strings.HasPrefix(uri, "https://") {
+		return uri
+	}
+	return base + uri
+}
+
+// parseStreamInfAttributes parses the comma-separated attribute list of
+// a #EXT-X-STREAM-INF tag (BANDWIDTH, RESOLUTION, CODECS).
+func parseStreamInfAttributes(attrs string) hlsVariant {
+	v := hlsVariant{}
+
+	for _, pair := range splitHLSAttributes(attrs) {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+
+		key := strings.TrimSpace(kv[0])
+		value := strings.Trim(strings.TrimSpace(kv[1]), `"`)
+
+		switch key {
+		case "BANDWIDTH":
+			if n, err := strconv.Atoi(value); err == nil {
+				v.Bandwidth = n
+			}
+		case "RESOLUTION":
+			v.Resolution = value
+		case "CODECS":
+			v.Codecs = value
+		}
+	}
+
+	return v
+}
+
+// splitHLSAttributes splits a comma-separated attribute list, respecting
+// commas embedded inside quoted values (e.g. CODECS="avc1.4d401f,mp4a.40.2").
+func splitHLSAttributes(attrs string) []string {
+	parts := []string{}
+	inQuotes := false
+	start := 0
+
+	for i, r := range attrs {
+		switch r {
+		case '"':
+			inQuotes = !inQuotes
+		case ',':
+			if !inQuotes {
+				parts = append(parts, attrs[start:i])
+				start = i + 1
+			}
+		}
+	}
+	parts = append(parts, attrs[start:])
+
+	return parts
+}
+
+// pickLeadingVariant filters out variants using an unsupported codec and
+// returns the highest-bandwidth remaining variant, mirroring the ABR
+// "default rendition" selection used by mediamtx's HLS client.
+func pickLeadingVariant(variants []hlsVariant) hlsVariant {
+	supported := []hlsVariant{}
+	for _, v := range variants {
+		if !hasUnsupportedCodec(v.Codecs) {
+			supported = append(supported, v)
+		}
+	}
+
+	if len(supported) == 0 {
+		supported = variants
+	}
+
+	leading := supported[0]
+	for _, v := range supported[1:] {
+		if v.Bandwidth > leading.Bandwidth {
+			leading = v
+		}
+	}
+
+	return leading
+}
+
+func hasUnsupportedCodec(codecs string) bool {
+	for _, unsupported := range hlsUnsupportedCodecs {
+		if strings.Contains(strings.ToLower(codecs), unsupported) {
+			return true
+		}
+	}
+	return false
+}
+
+func NewHLSEndpoint() ApiEndpoint {
+	return &HLSEndpoint{
+		&ApiEndpointSchema{
+			path: HLS_ENDPOINT_PREFIX,
+		},
+	}
+}
@@ -0,0 +1,105 @@
+package endpoint
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseMasterPlaylist(t *testing.T) {
+	playlist := strings.Join([]string{
+		"#EXTM3U",
+		`#EXT-X-STREAM-INF:BANDWIDTH=800000,RESOLUTION=640x360,CODECS="avc1.4d401f,mp4a.40.2"`,
+		"low/index.m3u8",
+		`#EXT-X-STREAM-INF:BANDWIDTH=2800000,RESOLUTION=1920x1080,CODECS="avc1.640028,mp4a.40.2"`,
+		"https://cdn.example.com/high/index.m3u8",
+	}, "\n")
+
+	variants, err := parseMasterPlaylist(strings.NewReader(playlist), "https://cdn.example.com/master.m3u8")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(variants) != 2 {
+		t.Fatalf("expected 2 variants, got %d", len(variants))
+	}
+
+	if variants[0].URL != "https://cdn.example.com/low/index.m3u8" {
+		t.Errorf("expected relative variant URI to resolve against base, got %q", variants[0].URL)
+	}
+	if variants[0].Bandwidth != 800000 {
+		t.Errorf("expected bandwidth 800000, got %d", variants[0].Bandwidth)
+	}
+	if variants[1].URL != "https://cdn.example.com/high/index.m3u8" {
+		t.Errorf("expected absolute variant URI to pass through unchanged, got %q", variants[1].URL)
+	}
+}
+
+func TestPickLeadingVariant(t *testing.T) {
+	variants := []hlsVariant{
+		{URL: "low", Bandwidth: 800000, Codecs: "avc1.4d401f,mp4a.40.2"},
+		{URL: "high", Bandwidth: 2800000, Codecs: "avc1.640028,mp4a.40.2"},
+		{URL: "eac3", Bandwidth: 5000000, Codecs: "avc1.640028,ec-3"},
+	}
+
+	leading := pickLeadingVariant(variants)
+	if leading.URL != "high" {
+		t.Errorf("expected the highest-bandwidth supported variant, got %q", leading.URL)
+	}
+}
+
+func TestPickLeadingVariantAllUnsupported(t *testing.T) {
+	variants := []hlsVariant{
+		{URL: "a", Bandwidth: 1000, Codecs: "ec-3"},
+		{URL: "b", Bandwidth: 2000, Codecs: "ac-3"},
+	}
+
+	leading := pickLeadingVariant(variants)
+	if leading.URL != "b" {
+		t.Errorf("expected fallback to the highest-bandwidth variant when none are supported, got %q", leading.URL)
+	}
+}
+
+func TestSplitHLSAttributes(t *testing.T) {
+	parts := splitHLSAttributes(`BANDWIDTH=800000,RESOLUTION=640x360,CODECS="avc1.4d401f,mp4a.40.2"`)
+	expected := []string{`BANDWIDTH=800000`, `RESOLUTION=640x360`, `CODECS="avc1.4d401f,mp4a.40.2"`}
+
+	if len(parts) != len(expected) {
+		t.Fatalf("expected %d parts, got %d: %v", len(expected), len(parts), parts)
+	}
+	for i, p := range parts {
+		if p != expected[i] {
+			t.Errorf("part %d: expected %q, got %q", i, expected[i], p)
+		}
+	}
+}
+
+func TestHasUnsupportedCodec(t *testing.T) {
+	cases := []struct {
+		codecs string
+		want   bool
+	}{
+		{"avc1.4d401f,mp4a.40.2", false},
+		{"avc1.640028,ec-3", true},
+		{"AC-3", true},
+		{"", false},
+	}
+
+	for _, c := range cases {
+		if got := hasUnsupportedCodec(c.codecs); got != c.want {
+			t.Errorf("hasUnsupportedCodec(%q) = %v, want %v", c.codecs, got, c.want)
+		}
+	}
+}
+
+func TestParseStreamInfAttributes(t *testing.T) {
+	v := parseStreamInfAttributes(`BANDWIDTH=1280000,RESOLUTION=842x480,CODECS="avc1.4d401f,mp4a.40.2"`)
+
+	if v.Bandwidth != 1280000 {
+		t.Errorf("expected bandwidth 1280000, got %d", v.Bandwidth)
+	}
+	if v.Resolution != "842x480" {
+		t.Errorf("expected resolution 842x480, got %q", v.Resolution)
+	}
+	if v.Codecs != "avc1.4d401f,mp4a.40.2" {
+		t.Errorf("expected codecs to be preserved, got %q", v.Codecs)
+	}
+}
@@ -0,0 +1,8 @@
+package endpoint
+
+// GetKind returns the item's Kind (e.g. stream.StreamKindHLS), so
+// playback code can distinguish stream kinds without depending on
+// concrete endpoint response types.
+func (i *EndpointResponseItem) GetKind() string {
+	return i.Kind
+}
@@ -0,0 +1,38 @@
+package endpoint
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/juanvallejo/streaming-server/pkg/api/httpclient"
+	"github.com/juanvallejo/streaming-server/pkg/socket/connection"
+)
+
+const METRICS_ENDPOINT_PREFIX = "/metrics"
+
+// MetricsEndpoint implements ApiEndpoint. It reports each registered
+// CachedClient's cache hit/miss counters so operators can size its TTL.
+type MetricsEndpoint struct {
+	*ApiEndpointSchema
+
+	clients map[string]*httpclient.CachedClient
+}
+
+// Handle writes one hits/misses counter pair per registered client, in
+// Prometheus-style "metric{label} value" lines.
+func (e *MetricsEndpoint) Handle(connHandler connection.ConnectionHandler, segments []string, w http.ResponseWriter, r *http.Request) {
+	for name, c := range e.clients {
+		hits, misses := c.Stats()
+		fmt.Fprintf(w, "httpclient_cache_hits_total{endpoint=%q} %d\n", name, hits)
+		fmt.Fprintf(w, "httpclient_cache_misses_total{endpoint=%q} %d\n", name, misses)
+	}
+}
+
+func NewMetricsEndpoint(clients map[string]*httpclient.CachedClient) ApiEndpoint {
+	return &MetricsEndpoint{
+		ApiEndpointSchema: &ApiEndpointSchema{
+			path: METRICS_ENDPOINT_PREFIX,
+		},
+		clients: clients,
+	}
+}
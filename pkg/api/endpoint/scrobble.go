@@ -0,0 +1,55 @@
+package endpoint
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/juanvallejo/streaming-server/pkg/scrobble"
+	"github.com/juanvallejo/streaming-server/pkg/socket/connection"
+)
+
+const SCROBBLE_ENDPOINT_PREFIX = "/scrobble"
+
+// ScrobbleEndpoint implements ApiEndpoint. It completes the OAuth-style
+// auth handshake for a scrobble.Scrobbler and stores the resulting
+// session on the scrobble.Manager shared with the socket handler.
+type ScrobbleEndpoint struct {
+	*ApiEndpointSchema
+
+	manager *scrobble.Manager
+}
+
+// Handle services "/scrobble/auth" callback requests. Scrobblers redirect
+// the user back here with a "scrobbler", "client" and "token" query
+// parameter once the user has authorized the app on their end.
+func (e *ScrobbleEndpoint) Handle(connHandler connection.ConnectionHandler, segments []string, w http.ResponseWriter, r *http.Request) {
+	if len(segments) < 2 || segments[1] != "auth" {
+		HandleEndpointError(fmt.Errorf("unimplemented endpoint"), w)
+		return
+	}
+
+	clientId := r.URL.Query().Get("client")
+	scrobblerId := r.URL.Query().Get("scrobbler")
+	token := r.URL.Query().Get("token")
+
+	if len(clientId) == 0 || len(scrobblerId) == 0 || len(token) == 0 {
+		HandleEndpointError(fmt.Errorf("not enough arguments: /scrobble/auth?client=&scrobbler=&token="), w)
+		return
+	}
+
+	e.manager.SetSession(clientId, &scrobble.Session{
+		ScrobblerId: scrobblerId,
+		Token:       token,
+	})
+
+	w.Write([]byte(fmt.Sprintf("%q scrobbling enabled. You may close this window.", scrobblerId)))
+}
+
+func NewScrobbleEndpoint(manager *scrobble.Manager) ApiEndpoint {
+	return &ScrobbleEndpoint{
+		ApiEndpointSchema: &ApiEndpointSchema{
+			path: SCROBBLE_ENDPOINT_PREFIX,
+		},
+		manager: manager,
+	}
+}
@@ -1,14 +1,16 @@
 package endpoint
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
 
 	"github.com/juanvallejo/streaming-server/pkg/api/config"
+	"github.com/juanvallejo/streaming-server/pkg/api/httpclient"
 	"github.com/juanvallejo/streaming-server/pkg/socket/connection"
 )
 
@@ -36,9 +38,42 @@ type SoundCloudItem struct {
 	Permalink string `json:"permalink_url"`
 	Artwork   string `json:"artwork_url"`
 
+	// Title and User back scrobble.Track metadata for now-playing/scrobble
+	// submissions. SoundCloud has no dedicated artist field, so the
+	// uploading user's username is used in its place.
+	Title string             `json:"title"`
+	User  SoundCloudItemUser `json:"user"`
+
+	// DurationMs is the track length in milliseconds, as returned by the
+	// SoundCloud API. Backs scrobble.Track.Duration via GetDuration.
+	DurationMs int `json:"duration"`
+
 	Errors []SoundCloudEndpointError `json:"errors"`
 }
 
+// SoundCloudItemUser holds the subset of SoundCloud's "user" object
+// needed to attribute a track's artist.
+type SoundCloudItemUser struct {
+	Username string `json:"username"`
+}
+
+// GetArtist returns the item's uploading user's username, used as the
+// artist field when reporting scrobble.Track metadata.
+func (i *SoundCloudItem) GetArtist() string {
+	return i.User.Username
+}
+
+// GetTitle returns the item's track title, used as the title field when
+// reporting scrobble.Track metadata. Satisfies scrobble.TrackSource.
+func (i *SoundCloudItem) GetTitle() string {
+	return i.Title
+}
+
+// GetDuration returns the item's track length. Satisfies scrobble.TrackSource.
+func (i *SoundCloudItem) GetDuration() time.Duration {
+	return time.Duration(i.DurationMs) * time.Millisecond
+}
+
 type SoundCloudEndpointError struct {
 	Message string `json:"error_message"`
 }
@@ -50,6 +85,8 @@ type SoundCloudEndpointResponse struct {
 // SoundCloudEndpoint implements ApiEndpoint
 type SoundCloudEndpoint struct {
 	*ApiEndpointSchema
+
+	httpClient *httpclient.CachedClient
 }
 
 // Handle returns a "discovery" of all local streams in the server data root.
@@ -72,7 +109,7 @@ func (e *SoundCloudEndpoint) Handle(connHandler connection.ConnectionHandler, se
 			return
 		}
 
-		handleSoundCloudApiSearch(segments[2], w)
+		e.handleSoundCloudApiSearch(r.Context(), segments[2], w)
 		return
 	case segments[1] == "stream":
 		if len(segments) < 3 {
@@ -80,31 +117,27 @@ func (e *SoundCloudEndpoint) Handle(connHandler connection.ConnectionHandler, se
 			return
 		}
 
-		handleSoundCloudApiStream(strings.Join(segments[2:], "/"), w)
+		e.handleSoundCloudApiStream(r.Context(), strings.Join(segments[2:], "/"), w)
 		return
 	}
 
 	HandleEndpointError(fmt.Errorf("unimplemented parameter"), w)
 }
 
-func handleSoundCloudApiSearch(query string, w http.ResponseWriter) {
+func (e *SoundCloudEndpoint) handleSoundCloudApiSearch(ctx context.Context, query string, w http.ResponseWriter) {
 	reqUrl := fmt.Sprintf(soundCloudSearchEndpointTemplate, query, config.SC_API_KEY)
-	handleSoundCloudApiRequest(reqUrl, w)
+	e.handleSoundCloudApiRequest(ctx, reqUrl, w)
 }
 
-func handleSoundCloudApiStream(rawPermalink string, w http.ResponseWriter) {
+func (e *SoundCloudEndpoint) handleSoundCloudApiStream(ctx context.Context, rawPermalink string, w http.ResponseWriter) {
 	permalink := url.QueryEscape(rawPermalink)
 
-	// resolve permalink into track id
+	// resolve permalink into track id. Bypass the cache entirely: the
+	// resolved response embeds a short-lived, session-specific stream
+	// URL, and serving it back to a later joiner after it expires would
+	// hand them a broken playback URL.
 	resolveUrl := fmt.Sprintf(soundCloudResolveEndpointTemplate, permalink, config.SC_API_KEY)
-	res, err := http.Get(resolveUrl)
-	if err != nil {
-		HandleEndpointError(err, w)
-		return
-	}
-
-	defer res.Body.Close()
-	data, err := ioutil.ReadAll(res.Body)
+	data, _, err := e.httpClient.GetNoStore(ctx, resolveUrl)
 	if err != nil {
 		HandleEndpointError(err, w)
 		return
@@ -122,7 +155,6 @@ func handleSoundCloudApiStream(rawPermalink string, w http.ResponseWriter) {
 	}
 
 	w.Write(respBytes)
-
 }
 
 func encodeApiResponse(data []byte) ([]byte, error) {
@@ -177,16 +209,35 @@ func encodeApiResponse(data []byte) ([]byte, error) {
 	return respBytes, nil
 }
 
-func handleSoundCloudApiRequest(reqUrl string, w http.ResponseWriter) {
-	res, err := http.Get(reqUrl)
+// FetchPlaylistItems implements sync.Fetcher for SoundCloud. It re-runs
+// the same e.httpClient.Get path as handleSoundCloudApiRequest against
+// playlistUrl and returns the permalink
+// of every track the playlist currently contains, for pkg/sync to track
+// and re-enqueue as they appear.
+func (e *SoundCloudEndpoint) FetchPlaylistItems(ctx context.Context, playlistUrl string) ([]string, error) {
+	data, status, err := e.httpClient.Get(ctx, playlistUrl)
 	if err != nil {
-		HandleEndpointError(err, w)
-		return
+		return nil, err
+	}
+	if status >= http.StatusBadRequest {
+		return nil, fmt.Errorf("error: soundcloud returned status %d for playlist %q", status, playlistUrl)
 	}
 
-	defer res.Body.Close()
+	playlist := &SoundCloudPlaylist{}
+	if err := json.Unmarshal(data, playlist); err != nil {
+		return nil, err
+	}
+
+	urls := make([]string, 0, len(playlist.Tracks))
+	for _, track := range playlist.Tracks {
+		urls = append(urls, track.Permalink)
+	}
+
+	return urls, nil
+}
 
-	data, err := ioutil.ReadAll(res.Body)
+func (e *SoundCloudEndpoint) handleSoundCloudApiRequest(ctx context.Context, reqUrl string, w http.ResponseWriter) {
+	data, _, err := e.httpClient.Get(ctx, reqUrl)
 	if err != nil {
 		HandleEndpointError(err, w)
 		return
@@ -201,10 +252,11 @@ func handleSoundCloudApiRequest(reqUrl string, w http.ResponseWriter) {
 	w.Write(respBytes)
 }
 
-func NewSoundCloudEndpoint() ApiEndpoint {
+func NewSoundCloudEndpoint(httpClient *httpclient.CachedClient) ApiEndpoint {
 	return &SoundCloudEndpoint{
-		&ApiEndpointSchema{
+		ApiEndpointSchema: &ApiEndpointSchema{
 			path: SC_ENDPOINT_PREFIX,
 		},
+		httpClient: httpClient,
 	}
 }
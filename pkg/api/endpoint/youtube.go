@@ -1,15 +1,36 @@
 package endpoint
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
-	"io/ioutil"
 	"net/http"
 
 	"github.com/juanvallejo/streaming-server/pkg/api/config"
+	"github.com/juanvallejo/streaming-server/pkg/api/httpclient"
 )
 
+const youtubeWatchUrlTemplate = "https://www.youtube.com/watch?v=%s"
+
+// youtubeSearchResponse holds the subset of the googleapis search response
+// needed to extract video ids and, for handleApiSearch, enough of each
+// result's snippet to back a scrobble.Track.
+type youtubeSearchResponse struct {
+	Items []struct {
+		Id struct {
+			VideoId string `json:"videoId"`
+		} `json:"id"`
+		Snippet struct {
+			Title        string `json:"title"`
+			ChannelTitle string `json:"channelTitle"`
+		} `json:"snippet"`
+	} `json:"items"`
+}
+
 const YOUTUBE_ENDPOINT_PREFIX = "/youtube"
 
+const YoutubeSearchItem = "youtube#searchItem"
+
 var (
 	youtubeMaxResults       = 20
 	youtubeEndpointTemplate = "https://www.googleapis.com/youtube/v3/search?part=snippet&q=%v&type=video&maxResults=%v&key=%v"
@@ -18,6 +39,8 @@ var (
 // YoutubeEndpoint implements ApiEndpoint
 type YoutubeEndpoint struct {
 	*ApiEndpointSchema
+
+	httpClient *httpclient.CachedClient
 }
 
 // Handle returns a "discovery" of all local streams in the server data root.
@@ -29,34 +52,105 @@ func (e *YoutubeEndpoint) Handle(segments []string, w http.ResponseWriter, r *ht
 			return
 		}
 
-		handleApiSearch(segments[2], w)
+		e.handleApiSearch(r.Context(), segments[2], w)
 		return
 	}
 
 	HandleEndpointError(fmt.Errorf("unimplemented parameter"), w)
 }
 
-func handleApiSearch(searchQuery string, w http.ResponseWriter) {
+func (e *YoutubeEndpoint) handleApiSearch(ctx context.Context, searchQuery string, w http.ResponseWriter) {
 	reqUrl := fmt.Sprintf(youtubeEndpointTemplate, searchQuery, youtubeMaxResults, config.YT_API_KEY)
-	res, err := http.Get(reqUrl)
+	data, _, err := e.httpClient.Get(ctx, reqUrl)
 	if err != nil {
 		HandleEndpointError(err, w)
 		return
 	}
 
-	data, err := ioutil.ReadAll(res.Body)
+	respBytes, err := encodeYoutubeSearchResponse(data)
 	if err != nil {
 		HandleEndpointError(err, w)
 		return
 	}
 
-	w.Write(data)
+	w.Write(respBytes)
+}
+
+// encodeYoutubeSearchResponse re-parses a raw googleapis search response
+// and wraps each result in a SoundCloudItem, mirroring how hls.go reuses
+// SoundCloudItem/SoundCloudEndpointResponse as this server's generic
+// response envelope rather than a SoundCloud-specific one. Title/User are
+// populated from the result's snippet so the item satisfies
+// scrobble.TrackSource the same way a SoundCloud track does.
+//
+// DurationMs is left unset: YouTube's search.list API does not return a
+// video's length (that requires a separate videos.list contentDetails
+// call this server does not make), so YouTube tracks are reported to a
+// Scrobbler with a zero duration and never clear MaybeScrobble's
+// minimum-track-time threshold.
+func encodeYoutubeSearchResponse(data []byte) ([]byte, error) {
+	res := &youtubeSearchResponse{}
+	if err := json.Unmarshal(data, res); err != nil {
+		return nil, err
+	}
+
+	resp := &SoundCloudEndpointResponse{}
+	for _, result := range res.Items {
+		if len(result.Id.VideoId) == 0 {
+			continue
+		}
+
+		watchUrl := fmt.Sprintf(youtubeWatchUrlTemplate, result.Id.VideoId)
+		resp.Items = append(resp.Items, &SoundCloudItem{
+			EndpointResponseItem: &EndpointResponseItem{
+				Kind: YoutubeSearchItem,
+				Url:  watchUrl,
+			},
+			Permalink: watchUrl,
+			Title:     result.Snippet.Title,
+			User:      SoundCloudItemUser{Username: result.Snippet.ChannelTitle},
+		})
+	}
+
+	return json.Marshal(resp)
+}
+
+// FetchPlaylistItems implements sync.Fetcher for YouTube. This server has
+// no dedicated playlist-items API wired up, so it re-runs the same
+// handleApiSearch request path against searchQuery and returns a watch
+// URL per result, for pkg/sync to track and re-enqueue as new results
+// appear.
+func (e *YoutubeEndpoint) FetchPlaylistItems(ctx context.Context, searchQuery string) ([]string, error) {
+	reqUrl := fmt.Sprintf(youtubeEndpointTemplate, searchQuery, youtubeMaxResults, config.YT_API_KEY)
+	data, status, err := e.httpClient.Get(ctx, reqUrl)
+	if err != nil {
+		return nil, err
+	}
+	if status >= http.StatusBadRequest {
+		return nil, fmt.Errorf("error: youtube returned status %d for query %q", status, searchQuery)
+	}
+
+	res := &youtubeSearchResponse{}
+	if err := json.Unmarshal(data, res); err != nil {
+		return nil, err
+	}
+
+	urls := make([]string, 0, len(res.Items))
+	for _, item := range res.Items {
+		if len(item.Id.VideoId) == 0 {
+			continue
+		}
+		urls = append(urls, fmt.Sprintf(youtubeWatchUrlTemplate, item.Id.VideoId))
+	}
+
+	return urls, nil
 }
 
-func NewYoutubeEndpoint() ApiEndpoint {
+func NewYoutubeEndpoint(httpClient *httpclient.CachedClient) ApiEndpoint {
 	return &YoutubeEndpoint{
-		&ApiEndpointSchema{
+		ApiEndpointSchema: &ApiEndpointSchema{
 			path: YOUTUBE_ENDPOINT_PREFIX,
 		},
+		httpClient: httpClient,
 	}
 }
@@ -0,0 +1,260 @@
+// Package httpclient provides a caching HTTP client for the streaming
+// API endpoints, inspired by Navidrome's NewCachedHTTPClient: a bounded
+// LRU keyed by request URL, revalidated against upstream via ETag/
+// Last-Modified, with per-request timeouts and context propagation so
+// callers stop duplicating naked http.Get calls across endpoints.
+package httpclient
+
+import (
+	"container/list"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Config configures a CachedClient.
+type Config struct {
+	// Capacity bounds how many responses are retained in the LRU.
+	Capacity int
+	// TTL is the freshness window applied when the upstream response
+	// carries no Cache-Control max-age directive.
+	TTL time.Duration
+	// Timeout bounds every upstream request.
+	Timeout time.Duration
+}
+
+type cacheEntry struct {
+	url          string
+	body         []byte
+	statusCode   int
+	etag         string
+	lastModified string
+	expiresAt    time.Time
+}
+
+// CachedClient wraps an http.Client with a bounded LRU response cache
+// keyed by request URL, honoring Cache-Control/ETag/Last-Modified on
+// revalidation.
+type CachedClient struct {
+	client *http.Client
+	ttl    time.Duration
+
+	mu       sync.Mutex
+	capacity int
+	ll       *list.List
+	entries  map[string]*list.Element
+
+	hits   uint64
+	misses uint64
+}
+
+// NewCachedClient returns a CachedClient configured per cfg, defaulting
+// any unset knobs to sane values.
+func NewCachedClient(cfg Config) *CachedClient {
+	if cfg.Capacity <= 0 {
+		cfg.Capacity = 256
+	}
+	if cfg.TTL <= 0 {
+		cfg.TTL = 5 * time.Minute
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+
+	return &CachedClient{
+		client:   &http.Client{Timeout: cfg.Timeout},
+		ttl:      cfg.TTL,
+		capacity: cfg.Capacity,
+		ll:       list.New(),
+		entries:  map[string]*list.Element{},
+	}
+}
+
+// Get issues a GET request for url, threading ctx through to the
+// upstream request. A still-fresh cached body is returned without
+// touching the network; a stale one is revalidated via If-None-Match/
+// If-Modified-Since before being re-fetched.
+func (c *CachedClient) Get(ctx context.Context, url string) ([]byte, int, error) {
+	c.mu.Lock()
+	entry, cached := c.lookup(url)
+	var snapshot cacheEntry
+	if cached {
+		// Snapshot the fields we need below under the lock: entry is a
+		// shared *cacheEntry that a concurrent revalidation can mutate.
+		snapshot = *entry
+	}
+	c.mu.Unlock()
+
+	if cached && time.Now().Before(snapshot.expiresAt) {
+		atomic.AddUint64(&c.hits, 1)
+		return snapshot.body, snapshot.statusCode, nil
+	}
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	req = req.WithContext(ctx)
+
+	if cached {
+		if snapshot.etag != "" {
+			req.Header.Set("If-None-Match", snapshot.etag)
+		}
+		if snapshot.lastModified != "" {
+			req.Header.Set("If-Modified-Since", snapshot.lastModified)
+		}
+	}
+
+	res, err := c.client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotModified && cached {
+		c.mu.Lock()
+		entry.expiresAt = time.Now().Add(c.freshnessFor(res))
+		c.mu.Unlock()
+
+		atomic.AddUint64(&c.hits, 1)
+		return snapshot.body, http.StatusOK, nil
+	}
+
+	atomic.AddUint64(&c.misses, 1)
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Only cache successful responses not marked no-store/no-cache; a
+	// transient 4xx/5xx should be retried on the next request rather than
+	// served stale for a full TTL, and upstream has explicitly asked that
+	// a no-store/no-cache response (e.g. a short-lived signed URL) never
+	// be persisted at all.
+	if res.StatusCode >= 200 && res.StatusCode < 300 && !isNoStore(res.Header.Get("Cache-Control")) {
+		c.mu.Lock()
+		c.store(url, &cacheEntry{
+			url:          url,
+			body:         body,
+			statusCode:   res.StatusCode,
+			etag:         res.Header.Get("ETag"),
+			lastModified: res.Header.Get("Last-Modified"),
+			expiresAt:    time.Now().Add(c.freshnessFor(res)),
+		})
+		c.mu.Unlock()
+	}
+
+	return body, res.StatusCode, nil
+}
+
+// GetNoStore issues a GET request for url like Get, but never reads from
+// or writes to the cache, regardless of what Cache-Control the response
+// carries. Use it for calls that resolve a short-lived, session-specific
+// URL (e.g. SoundCloud's permalink-resolve), where serving a cached
+// response to a later caller would hand out a broken playback URL.
+func (c *CachedClient) GetNoStore(ctx context.Context, url string) ([]byte, int, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	req = req.WithContext(ctx)
+
+	res, err := c.client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer res.Body.Close()
+
+	atomic.AddUint64(&c.misses, 1)
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return body, res.StatusCode, nil
+}
+
+// Stats returns the cumulative hit/miss counts, for the "/metrics" endpoint.
+func (c *CachedClient) Stats() (hits, misses uint64) {
+	return atomic.LoadUint64(&c.hits), atomic.LoadUint64(&c.misses)
+}
+
+// freshnessFor returns how long a response should be considered fresh,
+// honoring a Cache-Control max-age directive when present.
+func (c *CachedClient) freshnessFor(res *http.Response) time.Duration {
+	if maxAge, ok := parseMaxAge(res.Header.Get("Cache-Control")); ok {
+		return time.Duration(maxAge) * time.Second
+	}
+	return c.ttl
+}
+
+func (c *CachedClient) lookup(url string) (*cacheEntry, bool) {
+	el, ok := c.entries[url]
+	if !ok {
+		return nil, false
+	}
+	c.ll.MoveToFront(el)
+	return el.Value.(*cacheEntry), true
+}
+
+// store inserts or updates url's cache entry, evicting the
+// least-recently-used entry once capacity is exceeded.
+func (c *CachedClient) store(url string, entry *cacheEntry) {
+	if el, ok := c.entries[url]; ok {
+		el.Value = entry
+		c.ll.MoveToFront(el)
+		return
+	}
+
+	el := c.ll.PushFront(entry)
+	c.entries[url] = el
+
+	for c.ll.Len() > c.capacity {
+		oldest := c.ll.Back()
+		if oldest == nil {
+			break
+		}
+		c.ll.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).url)
+	}
+}
+
+// isNoStore reports whether a Cache-Control header value carries a
+// "no-store" or "no-cache" directive, either of which means the response
+// must not be persisted in the cache.
+func isNoStore(cacheControl string) bool {
+	for _, directive := range strings.Split(cacheControl, ",") {
+		directive = strings.TrimSpace(directive)
+		if directive == "no-store" || directive == "no-cache" {
+			return true
+		}
+	}
+	return false
+}
+
+// parseMaxAge extracts the max-age directive (in seconds) from a
+// Cache-Control header value, if present.
+func parseMaxAge(cacheControl string) (int, bool) {
+	for _, directive := range strings.Split(cacheControl, ",") {
+		directive = strings.TrimSpace(directive)
+		if !strings.HasPrefix(directive, "max-age=") {
+			continue
+		}
+
+		seconds, err := strconv.Atoi(strings.TrimPrefix(directive, "max-age="))
+		if err != nil {
+			continue
+		}
+
+		return seconds, true
+	}
+
+	return 0, false
+}
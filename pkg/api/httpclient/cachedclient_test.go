@@ -0,0 +1,178 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCachedClientServesFreshResponseWithoutRefetch(t *testing.T) {
+	var requests int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&requests, 1)
+		w.Write([]byte("hello"))
+	}))
+	defer srv.Close()
+
+	c := NewCachedClient(Config{TTL: time.Minute})
+
+	for i := 0; i < 3; i++ {
+		body, status, err := c.Get(context.Background(), srv.URL)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if status != http.StatusOK {
+			t.Fatalf("expected 200, got %d", status)
+		}
+		if string(body) != "hello" {
+			t.Fatalf("expected body %q, got %q", "hello", body)
+		}
+	}
+
+	if got := atomic.LoadInt64(&requests); got != 1 {
+		t.Errorf("expected a single upstream request for a still-fresh entry, got %d", got)
+	}
+
+	hits, misses := c.Stats()
+	if hits != 2 {
+		t.Errorf("expected 2 cache hits, got %d", hits)
+	}
+	if misses != 1 {
+		t.Errorf("expected 1 cache miss, got %d", misses)
+	}
+}
+
+func TestCachedClientRevalidatesExpiredEntryViaETag(t *testing.T) {
+	var requests int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&requests, 1)
+
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte("hello"))
+	}))
+	defer srv.Close()
+
+	// A 1ns TTL is stale almost immediately, forcing every Get to revalidate
+	// (a TTL of 0 would fall back to NewCachedClient's 5 minute default).
+	c := NewCachedClient(Config{TTL: time.Nanosecond})
+
+	body, _, err := c.Get(context.Background(), srv.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(body) != "hello" {
+		t.Fatalf("expected body %q, got %q", "hello", body)
+	}
+
+	time.Sleep(time.Millisecond)
+
+	body, status, err := c.Get(context.Background(), srv.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if status != http.StatusOK {
+		t.Errorf("expected a 304 revalidation to surface as 200 to the caller, got %d", status)
+	}
+	if string(body) != "hello" {
+		t.Errorf("expected the cached body to be served after a 304, got %q", body)
+	}
+
+	if got := atomic.LoadInt64(&requests); got != 2 {
+		t.Errorf("expected exactly 2 upstream requests (initial fetch + revalidation), got %d", got)
+	}
+}
+
+func TestCachedClientDoesNotCacheNoStore(t *testing.T) {
+	var requests int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&requests, 1)
+		w.Header().Set("Cache-Control", "no-store")
+		w.Write([]byte("hello"))
+	}))
+	defer srv.Close()
+
+	c := NewCachedClient(Config{TTL: time.Minute})
+
+	for i := 0; i < 2; i++ {
+		if _, _, err := c.Get(context.Background(), srv.URL); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if got := atomic.LoadInt64(&requests); got != 2 {
+		t.Errorf("expected a no-store response to be re-fetched every time, got %d requests", got)
+	}
+}
+
+func TestCachedClientEvictsLeastRecentlyUsed(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.Path))
+	}))
+	defer srv.Close()
+
+	c := NewCachedClient(Config{TTL: time.Minute, Capacity: 2})
+
+	urls := []string{srv.URL + "/a", srv.URL + "/b", srv.URL + "/c"}
+	for _, u := range urls {
+		if _, _, err := c.Get(context.Background(), u); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if c.ll.Len() != 2 {
+		t.Fatalf("expected the LRU to hold at most 2 entries, got %d", c.ll.Len())
+	}
+	if _, ok := c.entries[urls[0]]; ok {
+		t.Errorf("expected the least-recently-used entry %q to have been evicted", urls[0])
+	}
+	if _, ok := c.entries[urls[2]]; !ok {
+		t.Errorf("expected the most recently fetched entry %q to still be cached", urls[2])
+	}
+}
+
+func TestIsNoStore(t *testing.T) {
+	cases := []struct {
+		cacheControl string
+		want         bool
+	}{
+		{"no-store", true},
+		{"no-cache", true},
+		{"public, max-age=60", false},
+		{"", false},
+		{"private, no-store", true},
+	}
+
+	for _, c := range cases {
+		if got := isNoStore(c.cacheControl); got != c.want {
+			t.Errorf("isNoStore(%q) = %v, want %v", c.cacheControl, got, c.want)
+		}
+	}
+}
+
+func TestParseMaxAge(t *testing.T) {
+	cases := []struct {
+		cacheControl string
+		wantSeconds  int
+		wantOK       bool
+	}{
+		{"max-age=60", 60, true},
+		{"public, max-age=3600", 3600, true},
+		{"no-store", 0, false},
+		{"max-age=notanumber", 0, false},
+	}
+
+	for _, c := range cases {
+		seconds, ok := parseMaxAge(c.cacheControl)
+		if ok != c.wantOK || seconds != c.wantSeconds {
+			t.Errorf("parseMaxAge(%q) = (%d, %v), want (%d, %v)", c.cacheControl, seconds, ok, c.wantSeconds, c.wantOK)
+		}
+	}
+}
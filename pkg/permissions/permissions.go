@@ -0,0 +1,117 @@
+// Package permissions implements per-room, per-user permission bitsets,
+// modeled on the audio/video permission split used by signaling servers
+// such as nextcloud-spreed-signaling, adapted to this server's playback
+// and chat commands.
+package permissions
+
+import "sync"
+
+// Permission is a bitset of capabilities a client may hold within a room.
+type Permission uint32
+
+const (
+	PermChat Permission = 1 << iota
+	PermQueue
+	PermSkip
+	PermStreamControl
+	PermKick
+	PermChangeStream
+
+	// PermDefault is granted to every client on joining a room.
+	PermDefault = PermChat | PermQueue | PermSkip | PermChangeStream
+
+	// PermOwner is granted to a room's owner, in addition to PermDefault.
+	PermOwner = PermDefault | PermStreamControl | PermKick
+)
+
+// Has reports whether p holds every bit set in check.
+func (p Permission) Has(check Permission) bool {
+	return p&check == check
+}
+
+// Registry tracks per-room permission grants and room-owner metadata.
+// The first client to join a fresh room becomes its owner via EnsureOwner.
+type Registry struct {
+	mu     sync.RWMutex
+	grants map[string]map[string]Permission // room -> clientId -> bits
+	owners map[string]string                // room -> owner clientId
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		grants: map[string]map[string]Permission{},
+		owners: map[string]string{},
+	}
+}
+
+// EnsureOwner assigns clientId as room's owner if room has no owner yet,
+// granting it PermOwner. Returns true if clientId became the owner.
+func (r *Registry) EnsureOwner(room, clientId string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.owners[room]; exists {
+		return false
+	}
+
+	r.owners[room] = clientId
+	r.setLocked(room, clientId, PermOwner)
+	return true
+}
+
+// IsOwner reports whether clientId owns room.
+func (r *Registry) IsOwner(room, clientId string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.owners[room] == clientId
+}
+
+// Get returns clientId's current permission bits for room, defaulting to
+// PermDefault if the client holds no explicit grant yet.
+func (r *Registry) Get(room, clientId string) Permission {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if bits, ok := r.grants[room][clientId]; ok {
+		return bits
+	}
+	return PermDefault
+}
+
+// Has reports whether clientId currently holds every bit in check for room.
+func (r *Registry) Has(room, clientId string, check Permission) bool {
+	return r.Get(room, clientId).Has(check)
+}
+
+// Grant adds bits to clientId's permissions for room.
+func (r *Registry) Grant(room, clientId string, bits Permission) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.setLocked(room, clientId, r.getLocked(room, clientId)|bits)
+}
+
+// Revoke removes bits from clientId's permissions for room and reports
+// the resulting permission set, so callers can react to a downgrade
+// (e.g. revoking privileged state tied to a now-missing bit).
+func (r *Registry) Revoke(room, clientId string, bits Permission) Permission {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	remaining := r.getLocked(room, clientId) &^ bits
+	r.setLocked(room, clientId, remaining)
+	return remaining
+}
+
+func (r *Registry) getLocked(room, clientId string) Permission {
+	if bits, ok := r.grants[room][clientId]; ok {
+		return bits
+	}
+	return PermDefault
+}
+
+func (r *Registry) setLocked(room, clientId string, bits Permission) {
+	if _, ok := r.grants[room]; !ok {
+		r.grants[room] = map[string]Permission{}
+	}
+	r.grants[room][clientId] = bits
+}
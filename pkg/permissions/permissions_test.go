@@ -0,0 +1,94 @@
+package permissions
+
+import "testing"
+
+func TestPermissionHas(t *testing.T) {
+	p := PermChat | PermQueue
+
+	if !p.Has(PermChat) {
+		t.Error("expected PermChat to be held")
+	}
+	if p.Has(PermSkip) {
+		t.Error("did not expect PermSkip to be held")
+	}
+	if !p.Has(PermChat | PermQueue) {
+		t.Error("expected the combined bits to be held")
+	}
+	if p.Has(PermChat | PermSkip) {
+		t.Error("did not expect a check requiring a bit not held to pass")
+	}
+}
+
+func TestRegistryGetDefaultsWithoutExplicitGrant(t *testing.T) {
+	r := NewRegistry()
+
+	if got := r.Get("room", "client-1"); got != PermDefault {
+		t.Errorf("expected PermDefault for an ungranted client, got %v", got)
+	}
+}
+
+func TestRegistryEnsureOwnerGrantsPermOwnerToFirstClientOnly(t *testing.T) {
+	r := NewRegistry()
+
+	if !r.EnsureOwner("room", "client-1") {
+		t.Fatal("expected the first client to become owner")
+	}
+	if r.EnsureOwner("room", "client-2") {
+		t.Error("did not expect a second client to become owner of an already-owned room")
+	}
+
+	if !r.IsOwner("room", "client-1") {
+		t.Error("expected client-1 to be room's owner")
+	}
+	if r.IsOwner("room", "client-2") {
+		t.Error("did not expect client-2 to be room's owner")
+	}
+
+	if got := r.Get("room", "client-1"); got != PermOwner {
+		t.Errorf("expected owner to hold PermOwner, got %v", got)
+	}
+}
+
+func TestRegistryGrant(t *testing.T) {
+	r := NewRegistry()
+
+	r.Grant("room", "client-1", PermKick)
+
+	if !r.Has("room", "client-1", PermKick) {
+		t.Error("expected PermKick to have been granted")
+	}
+	// Grant should add to the default set, not replace it.
+	if !r.Has("room", "client-1", PermDefault) {
+		t.Error("expected the default permission set to still be held after a grant")
+	}
+}
+
+func TestRegistryRevoke(t *testing.T) {
+	r := NewRegistry()
+	r.EnsureOwner("room", "client-1")
+
+	remaining := r.Revoke("room", "client-1", PermStreamControl|PermKick)
+
+	if remaining.Has(PermStreamControl) || remaining.Has(PermKick) {
+		t.Errorf("expected revoked bits to be cleared, got %v", remaining)
+	}
+	if !remaining.Has(PermDefault) {
+		t.Errorf("expected unrelated default bits to survive a revoke, got %v", remaining)
+	}
+	if r.Has("room", "client-1", PermStreamControl) {
+		t.Error("expected a subsequent Has check to reflect the revoke")
+	}
+}
+
+func TestRegistryRevokeFromUngrantedClientStartsFromDefault(t *testing.T) {
+	r := NewRegistry()
+
+	remaining := r.Revoke("room", "client-1", PermSkip)
+
+	if remaining.Has(PermSkip) {
+		t.Error("expected PermSkip to be cleared")
+	}
+	if !remaining.Has(PermChat) || !remaining.Has(PermQueue) {
+		t.Errorf("expected the rest of PermDefault to remain, got %v", remaining)
+	}
+}
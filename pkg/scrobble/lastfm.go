@@ -0,0 +1,109 @@
+package scrobble
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+)
+
+const (
+	lastFMId       = "lastfm"
+	lastFMEndpoint = "https://ws.audioscrobbler.com/2.0/"
+)
+
+// LastFMScrobbler implements Scrobbler against the Last.fm Audioscrobbler API.
+type LastFMScrobbler struct {
+	apiKey    string
+	apiSecret string
+}
+
+// NewLastFMScrobbler returns a Scrobbler that authenticates against the
+// Last.fm API using the given application credentials.
+func NewLastFMScrobbler(apiKey, apiSecret string) *LastFMScrobbler {
+	return &LastFMScrobbler{
+		apiKey:    apiKey,
+		apiSecret: apiSecret,
+	}
+}
+
+func (s *LastFMScrobbler) Id() string {
+	return lastFMId
+}
+
+func (s *LastFMScrobbler) UpdateNowPlaying(session *Session, track Track) error {
+	params := map[string]string{
+		"method": "track.updateNowPlaying",
+		"artist": track.Artist,
+		"track":  track.Title,
+		"album":  track.Album,
+		"sk":     session.Token,
+	}
+
+	return s.call(params)
+}
+
+func (s *LastFMScrobbler) Scrobble(session *Session, track Track, startedAt time.Time) error {
+	params := map[string]string{
+		"method":    "track.scrobble",
+		"artist":    track.Artist,
+		"track":     track.Title,
+		"album":     track.Album,
+		"timestamp": fmt.Sprintf("%d", startedAt.Unix()),
+		"sk":        session.Token,
+	}
+
+	return s.call(params)
+}
+
+// call signs params per the Last.fm API signature spec and issues the request.
+func (s *LastFMScrobbler) call(params map[string]string) error {
+	params["api_key"] = s.apiKey
+	params["api_sig"] = s.sign(params)
+
+	form := url.Values{}
+	for k, v := range params {
+		form.Set(k, v)
+	}
+	form.Set("format", "json")
+
+	res, err := http.PostForm(lastFMEndpoint, form)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	data, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("error: last.fm request failed (%d): %s", res.StatusCode, data)
+	}
+
+	return nil
+}
+
+// sign computes the Last.fm API method signature: params sorted by key,
+// concatenated as key+value, suffixed with the shared secret, and md5'd.
+func (s *LastFMScrobbler) sign(params map[string]string) string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	raw := ""
+	for _, k := range keys {
+		raw += k + params[k]
+	}
+	raw += s.apiSecret
+
+	sum := md5.Sum([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,95 @@
+package scrobble
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const (
+	listenBrainzId       = "listenbrainz"
+	listenBrainzEndpoint = "https://api.listenbrainz.org/1/submit-listens"
+)
+
+// ListenBrainzScrobbler implements Scrobbler against the ListenBrainz
+// "submit-listens" API.
+type ListenBrainzScrobbler struct{}
+
+// NewListenBrainzScrobbler returns a Scrobbler that submits listens to
+// ListenBrainz. ListenBrainz authenticates per-user via a token rather
+// than an application key, so the Session.Token is the user's token.
+func NewListenBrainzScrobbler() *ListenBrainzScrobbler {
+	return &ListenBrainzScrobbler{}
+}
+
+func (s *ListenBrainzScrobbler) Id() string {
+	return listenBrainzId
+}
+
+func (s *ListenBrainzScrobbler) UpdateNowPlaying(session *Session, track Track) error {
+	return s.submit(session, "playing_now", []listenBrainzListen{{
+		TrackMetadata: listenBrainzMetadata{
+			ArtistName:  track.Artist,
+			TrackName:   track.Title,
+			ReleaseName: track.Album,
+		},
+	}})
+}
+
+func (s *ListenBrainzScrobbler) Scrobble(session *Session, track Track, startedAt time.Time) error {
+	return s.submit(session, "single", []listenBrainzListen{{
+		ListenedAt: startedAt.Unix(),
+		TrackMetadata: listenBrainzMetadata{
+			ArtistName:  track.Artist,
+			TrackName:   track.Title,
+			ReleaseName: track.Album,
+		},
+	}})
+}
+
+type listenBrainzMetadata struct {
+	ArtistName  string `json:"artist_name"`
+	TrackName   string `json:"track_name"`
+	ReleaseName string `json:"release_name,omitempty"`
+}
+
+type listenBrainzListen struct {
+	ListenedAt    int64                `json:"listened_at,omitempty"`
+	TrackMetadata listenBrainzMetadata `json:"track_metadata"`
+}
+
+type listenBrainzPayload struct {
+	ListenType string               `json:"listen_type"`
+	Payload    []listenBrainzListen `json:"payload"`
+}
+
+func (s *ListenBrainzScrobbler) submit(session *Session, listenType string, listens []listenBrainzListen) error {
+	body, err := json.Marshal(&listenBrainzPayload{
+		ListenType: listenType,
+		Payload:    listens,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, listenBrainzEndpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("Token %s", session.Token))
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("error: listenbrainz request failed with status %d", res.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,177 @@
+// Package scrobble implements pluggable scrobbling agents (Last.fm,
+// ListenBrainz) that report "now playing" and "scrobble" events for
+// tracks played back in a room.
+package scrobble
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// Last.fm scrobble rules: a track qualifies for a scrobble once it has
+// played for at least half of its duration, or for ScrobbleMinDuration,
+// whichever is reached first.
+const (
+	ScrobbleMinDuration  = 4 * time.Minute
+	ScrobbleMinPercent   = 0.5
+	ScrobbleMinTrackTime = 30 * time.Second // tracks shorter than this are never scrobbled
+)
+
+// Track describes the now-playing metadata submitted to a Scrobbler.
+type Track struct {
+	Artist   string
+	Title    string
+	Album    string
+	Duration time.Duration
+}
+
+// TrackSource is satisfied by a stream's GetInfo() payload when it can
+// supply scrobble.Track metadata (e.g. SoundCloudItem). Stream kinds that
+// don't implement it are simply never reported to a Scrobbler.
+type TrackSource interface {
+	GetArtist() string
+	GetTitle() string
+	GetDuration() time.Duration
+}
+
+// TrackFromSource builds a Track from src, for StreamPlayback's
+// "track start" lifecycle callback.
+func TrackFromSource(src TrackSource) Track {
+	return Track{Artist: src.GetArtist(), Title: src.GetTitle(), Duration: src.GetDuration()}
+}
+
+// Scrobbler submits now-playing and scrobble events to an external
+// music-tracking service on behalf of a single authenticated user.
+type Scrobbler interface {
+	// Id returns the lowercase identifier for this scrobbler (e.g. "lastfm").
+	Id() string
+	// UpdateNowPlaying notifies the service that track has begun playing.
+	UpdateNowPlaying(session *Session, track Track) error
+	// Scrobble submits a completed listen for track, started at startedAt.
+	Scrobble(session *Session, track Track, startedAt time.Time) error
+}
+
+// Session holds the OAuth-style credentials issued to a user by a
+// Scrobbler after completing its "/scrobble/auth" handshake.
+type Session struct {
+	ScrobblerId string
+	Token       string
+	Username    string
+}
+
+// Manager tracks per-client scrobbler sessions and fans out
+// now-playing/scrobble calls from StreamPlayback lifecycle callbacks.
+// Sessions are keyed by client id rather than stored on client.Client
+// itself: the OAuth-style handshake that produces them completes on
+// ScrobbleEndpoint, a stateless HTTP callback that only ever sees the
+// client id from the redirect's query string, never a live *client.Client.
+type Manager struct {
+	mu         sync.RWMutex
+	scrobblers map[string]Scrobbler
+	sessions   map[string]map[string]*Session // clientId -> scrobblerId -> Session
+	enabled    map[string]bool                // clientId -> opted in via "/scrobble"
+}
+
+// NewManager returns a Manager with the given Scrobbler agents registered.
+func NewManager(scrobblers ...Scrobbler) *Manager {
+	m := &Manager{
+		scrobblers: map[string]Scrobbler{},
+		sessions:   map[string]map[string]*Session{},
+		enabled:    map[string]bool{},
+	}
+
+	for _, s := range scrobblers {
+		m.scrobblers[s.Id()] = s
+	}
+
+	return m
+}
+
+// SetEnabled toggles scrobbling for a given client id, used by the
+// "/scrobble" chat command.
+func (m *Manager) SetEnabled(clientId string, enabled bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.enabled[clientId] = enabled
+}
+
+// IsEnabled reports whether a client has scrobbling turned on.
+func (m *Manager) IsEnabled(clientId string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.enabled[clientId]
+}
+
+// SetSession stores the session issued to clientId by the named scrobbler
+// and implicitly opts the client into scrobbling.
+func (m *Manager) SetSession(clientId string, session *Session) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.sessions[clientId]; !ok {
+		m.sessions[clientId] = map[string]*Session{}
+	}
+	m.sessions[clientId][session.ScrobblerId] = session
+	m.enabled[clientId] = true
+}
+
+// NowPlaying notifies every scrobbler session associated with clientId
+// that track has begun playing. Registered as a StreamPlayback
+// "track start" lifecycle callback.
+func (m *Manager) NowPlaying(clientId string, track Track) {
+	if !m.IsEnabled(clientId) {
+		return
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for scrobblerId, session := range m.sessions[clientId] {
+		scrobbler, ok := m.scrobblers[scrobblerId]
+		if !ok {
+			continue
+		}
+
+		go func(s Scrobbler, sess *Session, t Track) {
+			if err := s.UpdateNowPlaying(sess, t); err != nil {
+				log.Printf("ERR SCROBBLE unable to update now-playing via %q: %v", s.Id(), err)
+			}
+		}(scrobbler, session, track)
+	}
+}
+
+// MaybeScrobble submits track as a scrobble once it has played for at
+// least the Last.fm/ListenBrainz threshold (>= 50% of its runtime, or
+// >= ScrobbleMinDuration, whichever is reached first). Registered as a
+// StreamPlayback "tick" lifecycle callback.
+func (m *Manager) MaybeScrobble(clientId string, track Track, elapsed time.Duration, startedAt time.Time) {
+	if track.Duration < ScrobbleMinTrackTime || !m.IsEnabled(clientId) {
+		return
+	}
+
+	threshold := time.Duration(float64(track.Duration) * ScrobbleMinPercent)
+	if threshold > ScrobbleMinDuration {
+		threshold = ScrobbleMinDuration
+	}
+
+	if elapsed < threshold {
+		return
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for scrobblerId, session := range m.sessions[clientId] {
+		scrobbler, ok := m.scrobblers[scrobblerId]
+		if !ok {
+			continue
+		}
+
+		go func(s Scrobbler, sess *Session, t Track, started time.Time) {
+			if err := s.Scrobble(sess, t, started); err != nil {
+				log.Printf("ERR SCROBBLE unable to submit scrobble via %q: %v", s.Id(), err)
+			}
+		}(scrobbler, session, track, startedAt)
+	}
+}
@@ -0,0 +1,175 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/juanvallejo/streaming-server/pkg/permissions"
+	"github.com/juanvallejo/streaming-server/pkg/playback"
+	"github.com/juanvallejo/streaming-server/pkg/socket/client"
+	"github.com/juanvallejo/streaming-server/pkg/stream"
+)
+
+const (
+	GrantCommandId   = "grant"
+	RevokeCommandId  = "revoke"
+	PromoteCommandId = "promote"
+)
+
+// permissionNames maps the argument names accepted by /grant and /revoke
+// to their underlying permissions.Permission bit.
+var permissionNames = map[string]permissions.Permission{
+	"chat":          permissions.PermChat,
+	"queue":         permissions.PermQueue,
+	"skip":          permissions.PermSkip,
+	"streamcontrol": permissions.PermStreamControl,
+	"kick":          permissions.PermKick,
+	"changestream":  permissions.PermChangeStream,
+}
+
+// findRoomClientByUsername returns the client named username in the same
+// room as caller, used to resolve the target of /grant, /revoke and
+// /promote.
+func findRoomClientByUsername(caller *client.Client, clientHandler client.SocketClientHandler, username string) (*client.Client, error) {
+	room, exists := caller.GetRoom()
+	if !exists {
+		return nil, fmt.Errorf("error: you are not in a room")
+	}
+
+	for _, c := range clientHandler.GetClients() {
+		name, hasName := c.GetUsername()
+		if !hasName || name != username {
+			continue
+		}
+
+		if r, exists := c.GetRoom(); !exists || r != room {
+			continue
+		}
+
+		return c, nil
+	}
+
+	return nil, fmt.Errorf("error: no user with username %q found in this room", username)
+}
+
+// broadcastPermissionChanged notifies target that its permission set has
+// just changed.
+func broadcastPermissionChanged(target *client.Client) {
+	target.BroadcastTo("info_permissionchanged", &client.Response{
+		Id: target.GetId(),
+	})
+}
+
+// GrantCommand implements StreamCommand. It grants a named permission to
+// another client in the caller's room. Usage: /grant <username> <permission>
+type GrantCommand struct {
+	registry *permissions.Registry
+}
+
+func NewGrantCommand(registry *permissions.Registry) *GrantCommand {
+	return &GrantCommand{registry: registry}
+}
+
+func (c *GrantCommand) GetId() string {
+	return GrantCommandId
+}
+
+func (c *GrantCommand) Execute(args []string, caller *client.Client, clientHandler client.SocketClientHandler, playbackHandler playback.StreamPlaybackHandler, streamHandler stream.StreamHandler) (string, error) {
+	if len(args) < 2 {
+		return "", fmt.Errorf("error: usage: /grant <username> <permission>")
+	}
+
+	bit, ok := permissionNames[args[1]]
+	if !ok {
+		return "", fmt.Errorf("error: unrecognized permission %q", args[1])
+	}
+
+	target, err := findRoomClientByUsername(caller, clientHandler, args[0])
+	if err != nil {
+		return "", err
+	}
+
+	room, _ := caller.GetRoom()
+	c.registry.Grant(room, target.UUID(), bit)
+	broadcastPermissionChanged(target)
+
+	return fmt.Sprintf("granted %q to %s", args[1], args[0]), nil
+}
+
+// RevokeCommand implements StreamCommand. It revokes a named permission
+// from another client in the caller's room. Usage: /revoke <username> <permission>
+type RevokeCommand struct {
+	registry *permissions.Registry
+}
+
+func NewRevokeCommand(registry *permissions.Registry) *RevokeCommand {
+	return &RevokeCommand{registry: registry}
+}
+
+func (c *RevokeCommand) GetId() string {
+	return RevokeCommandId
+}
+
+func (c *RevokeCommand) Execute(args []string, caller *client.Client, clientHandler client.SocketClientHandler, playbackHandler playback.StreamPlaybackHandler, streamHandler stream.StreamHandler) (string, error) {
+	if len(args) < 2 {
+		return "", fmt.Errorf("error: usage: /revoke <username> <permission>")
+	}
+
+	bit, ok := permissionNames[args[1]]
+	if !ok {
+		return "", fmt.Errorf("error: unrecognized permission %q", args[1])
+	}
+
+	target, err := findRoomClientByUsername(caller, clientHandler, args[0])
+	if err != nil {
+		return "", err
+	}
+
+	room, _ := caller.GetRoom()
+	remaining := c.registry.Revoke(room, target.UUID(), bit)
+	broadcastPermissionChanged(target)
+
+	// A downgrade below PermStreamControl should immediately drop any
+	// privileged playback state the target was holding, rather than
+	// leaving it active until the target's next action is rejected.
+	if bit.Has(permissions.PermStreamControl) && !remaining.Has(permissions.PermStreamControl) {
+		if sPlayback, exists := playbackHandler.GetStreamPlayback(room); exists {
+			if activeQueuerId, isActive := sPlayback.GetActiveQueuer(); isActive && activeQueuerId == target.UUID() {
+				sPlayback.RevokeActiveQueuer(target.UUID())
+			}
+		}
+	}
+
+	return fmt.Sprintf("revoked %q from %s", args[1], args[0]), nil
+}
+
+// PromoteCommand implements StreamCommand. It grants another client in
+// the caller's room the full PermOwner permission set, e.g. to hand off
+// stream control while the owner is away. Usage: /promote <username>
+type PromoteCommand struct {
+	registry *permissions.Registry
+}
+
+func NewPromoteCommand(registry *permissions.Registry) *PromoteCommand {
+	return &PromoteCommand{registry: registry}
+}
+
+func (c *PromoteCommand) GetId() string {
+	return PromoteCommandId
+}
+
+func (c *PromoteCommand) Execute(args []string, caller *client.Client, clientHandler client.SocketClientHandler, playbackHandler playback.StreamPlaybackHandler, streamHandler stream.StreamHandler) (string, error) {
+	if len(args) < 1 {
+		return "", fmt.Errorf("error: usage: /promote <username>")
+	}
+
+	target, err := findRoomClientByUsername(caller, clientHandler, args[0])
+	if err != nil {
+		return "", err
+	}
+
+	room, _ := caller.GetRoom()
+	c.registry.Grant(room, target.UUID(), permissions.PermOwner)
+	broadcastPermissionChanged(target)
+
+	return fmt.Sprintf("promoted %s to a room owner", args[0]), nil
+}
@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/juanvallejo/streaming-server/pkg/playback"
+	"github.com/juanvallejo/streaming-server/pkg/scrobble"
+	"github.com/juanvallejo/streaming-server/pkg/socket/client"
+	"github.com/juanvallejo/streaming-server/pkg/stream"
+)
+
+const ScrobbleCommandId = "scrobble"
+
+// ScrobbleCommand implements StreamCommand. It toggles scrobbling for the
+// calling client; the client must already hold a scrobbler session
+// obtained via the "/scrobble/auth" endpoint handshake.
+type ScrobbleCommand struct {
+	manager *scrobble.Manager
+}
+
+func NewScrobbleCommand(manager *scrobble.Manager) *ScrobbleCommand {
+	return &ScrobbleCommand{manager: manager}
+}
+
+func (c *ScrobbleCommand) GetId() string {
+	return ScrobbleCommandId
+}
+
+// Execute toggles scrobbling on/off for the calling client. With no
+// arguments, it reports the current state.
+func (c *ScrobbleCommand) Execute(args []string, client *client.Client, clientHandler client.SocketClientHandler, playbackHandler playback.StreamPlaybackHandler, streamHandler stream.StreamHandler) (string, error) {
+	clientId := client.UUID()
+
+	if len(args) == 0 {
+		if c.manager.IsEnabled(clientId) {
+			return "scrobbling is currently enabled", nil
+		}
+		return "scrobbling is currently disabled", nil
+	}
+
+	switch args[0] {
+	case "on":
+		c.manager.SetEnabled(clientId, true)
+		return "scrobbling enabled", nil
+	case "off":
+		c.manager.SetEnabled(clientId, false)
+		return "scrobbling disabled", nil
+	}
+
+	return "", fmt.Errorf("error: unrecognized /scrobble argument %q; expected \"on\" or \"off\"", args[0])
+}
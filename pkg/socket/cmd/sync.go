@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/juanvallejo/streaming-server/pkg/permissions"
+	"github.com/juanvallejo/streaming-server/pkg/playback"
+	"github.com/juanvallejo/streaming-server/pkg/socket/client"
+	"github.com/juanvallejo/streaming-server/pkg/socket/util"
+	"github.com/juanvallejo/streaming-server/pkg/stream"
+	"github.com/juanvallejo/streaming-server/pkg/sync"
+)
+
+const SyncCommandId = "sync"
+
+// SyncCommand implements StreamCommand. Usage:
+//
+//	/sync status
+//	/sync add <source> <url>
+//
+// "/sync add" is the only call site that reaches sync.Manager.Register:
+// it tracks <url> (a SoundCloud/YouTube playlist or search query) against
+// the caller's room so Manager's polling loop keeps discovering and
+// queuing new items from it. Since it both enqueues items and can
+// introduce streams the room wasn't already playing, it requires both
+// PermQueue and PermChangeStream.
+type SyncCommand struct {
+	manager  *sync.Manager
+	registry *permissions.Registry
+}
+
+func NewSyncCommand(manager *sync.Manager, registry *permissions.Registry) *SyncCommand {
+	return &SyncCommand{manager: manager, registry: registry}
+}
+
+func (c *SyncCommand) GetId() string {
+	return SyncCommandId
+}
+
+func (c *SyncCommand) Execute(args []string, caller *client.Client, clientHandler client.SocketClientHandler, playbackHandler playback.StreamPlaybackHandler, streamHandler stream.StreamHandler) (string, error) {
+	if len(args) < 1 {
+		return "", fmt.Errorf("error: usage: /sync status | /sync add <source> <url>")
+	}
+
+	room, exists := caller.GetRoom()
+	if !exists {
+		return "", fmt.Errorf("error: you are not in a room")
+	}
+
+	switch args[0] {
+	case "status":
+		counts := c.manager.StatusCounts(room)
+		return fmt.Sprintf("sync status: %d pending, %d queued, %d syncing, %d synced, %d failed",
+			counts[sync.StatusPending], counts[sync.StatusQueued], counts[sync.StatusSyncing], counts[sync.StatusSynced], counts[sync.StatusFailed]), nil
+	case "add":
+		if len(args) < 3 {
+			return "", fmt.Errorf("error: usage: /sync add <source> <url>")
+		}
+
+		if err := util.RequirePermission(c.registry, room, caller.UUID(), permissions.PermQueue|permissions.PermChangeStream); err != nil {
+			return "", err
+		}
+
+		if err := c.manager.Register(room, args[1], args[2]); err != nil {
+			return "", fmt.Errorf("error: unable to register playlist: %v", err)
+		}
+
+		return fmt.Sprintf("tracking %s playlist %s", args[1], args[2]), nil
+	}
+
+	return "", fmt.Errorf("error: usage: /sync status | /sync add <source> <url>")
+}
@@ -4,13 +4,17 @@ import (
 	"fmt"
 	"log"
 	"net/http"
-	"strings"
+	"sync"
+	"time"
 
 	sockio "github.com/googollee/go-socket.io"
 
+	"github.com/juanvallejo/streaming-server/pkg/permissions"
 	"github.com/juanvallejo/streaming-server/pkg/playback"
+	"github.com/juanvallejo/streaming-server/pkg/scrobble"
 	"github.com/juanvallejo/streaming-server/pkg/socket/client"
 	"github.com/juanvallejo/streaming-server/pkg/socket/cmd"
+	"github.com/juanvallejo/streaming-server/pkg/socket/router"
 	"github.com/juanvallejo/streaming-server/pkg/socket/util"
 	"github.com/juanvallejo/streaming-server/pkg/stream"
 )
@@ -20,15 +24,28 @@ type Handler struct {
 	CommandHandler  cmd.SocketCommandHandler
 	PlaybackHandler playback.StreamPlaybackHandler
 	StreamHandler   stream.StreamHandler
+	Permissions     *permissions.Registry
+	ScrobbleManager *scrobble.Manager
 
+	router *router.MessageRouter
 	server *Server
 }
 
 const (
 	ROOM_DEFAULT_LOBBY           = "lobby"
 	ROOM_DEFAULT_STREAMSYNC_RATE = 30 // send streamsync to clients every 30 seconds
+
+	// ROOM_DEFAULT_COMMAND_RATE bounds how often a single client may
+	// trigger the same routed event, to curb chat/command spam.
+	ROOM_DEFAULT_COMMAND_RATE = 250 * time.Millisecond
 )
 
+// HandleClientConnection registers conn with the client/room bookkeeping,
+// then binds every socket event this server understands to the
+// MessageRouter, which resolves a router.Context (client, room,
+// StreamPlayback) and runs it through the router's middleware chain
+// before invoking the matching HandlerFunc. See addRouterHandlers for the
+// registered event -> HandlerFunc mapping.
 func (h *Handler) HandleClientConnection(conn sockio.Socket) {
 	log.Printf("INFO SOCKET CONN client (%s) has connected with id %q\n", conn.Request().RemoteAddr, conn.Id())
 
@@ -36,122 +53,10 @@ func (h *Handler) HandleClientConnection(conn sockio.Socket) {
 	log.Printf("INFO SOCKET currently %v clients registered\n", h.clientHandler.GetClientSize())
 
 	// TODO: remove room's StreamPlayback once last client has left
-	conn.On("disconnection", func() {
-		log.Printf("INFO DCONN SOCKET client with id %q has disconnected\n", conn.Id())
-
-		if c, err := h.clientHandler.GetClient(conn.Id()); err == nil {
-			userName, exists := c.GetUsername()
-			if exists {
-				c.BroadcastFrom("info_clientleft", &client.Response{
-					Id:   conn.Id(),
-					From: userName,
-				})
-			}
-		}
-
-		err := h.DeregisterClient(conn)
-		if err != nil {
-			log.Printf("ERR SOCKET %v", err)
-		}
-	})
-
-	conn.On("request_updateusername", func(data map[string]string) {
-		username, ok := data["user"]
-		if !ok {
-			log.Printf("ERR SOCKET CLIENT client %q sent malformed request to update username. Ignoring request.", conn.Id())
-			return
-		}
-
-		c, err := h.clientHandler.GetClient(conn.Id())
-		if err != nil {
-			log.Printf("ERR SOCKET CLIENT %v. Broadcasting as info_clienterror event", err)
-			c.BroadcastErrorTo(err)
-			return
-		}
-
-		err = util.UpdateClientUsername(c, username, h.clientHandler, h.PlaybackHandler)
-		if err != nil {
-			log.Printf("ERR SOCKET CLIENT %v. Broadcasting as \"info_clienterror\" event", err)
-			c.BroadcastErrorTo(err)
-		}
-	})
-
-	conn.On("request_chatmessage", func(data map[string]interface{}) {
-		username, ok := data["user"]
-		if ok {
-			log.Printf("INFO SOCKET CLIENT client with id %q requested a chat message broadcast with name %q", conn.Id(), username)
-		}
-
-		c, err := h.clientHandler.GetClient(conn.Id())
-		if err != nil {
-			log.Printf("ERR SOCKET CLIENT could not retrieve client. Ignoring request_chatmessage request: %v", err)
-			return
-		}
-
-		err, command, isCommand := h.ParseCommandMessage(c, data)
-		if err != nil {
-			log.Printf("ERR SOCKET CLIENT unable to parse client chat message as command: %v", err)
-			c.BroadcastSystemMessageTo(err.Error())
-			return
-		}
-
-		if isCommand {
-			cmdSegments := strings.Split(command, " ")
-			cmdArgs := []string{}
-			if len(cmdSegments) > 1 {
-				cmdArgs = cmdSegments[1:]
-			}
-
-			log.Printf("INFO SOCKET CLIENT interpreting chat message as user command %q for client id (%q) with name %q", command, conn.Id(), username)
-			result, err := h.CommandHandler.ExecuteCommand(cmdSegments[0], cmdArgs, c, h.clientHandler, h.PlaybackHandler, h.StreamHandler)
-			if err != nil {
-				log.Printf("ERR SOCKET CLIENT unable to execute command with id %q: %v", command, err)
-				c.BroadcastSystemMessageTo(err.Error())
-				return
-			}
-
-			if len(result) > 0 {
-				c.BroadcastSystemMessageTo(result)
-			}
-			return
-		}
-
-		// TODO: parse message multimedia
-		// if err := h.ReplaceMessageImageURL(data); err != nil {
-		// 	log.Printf("SOCKET CLIENT WARN ")
-		// }
-
-		res := client.ResponseFromClientData(data)
-		c.BroadcastAll("chatmessage", &res)
-
-		fmt.Printf("INFO SOCKET CLIENT chatmessage received %v\n", data)
-	})
-
-	conn.On("request_streamsync", func(data map[string]interface{}) {
-		log.Printf("INFO SOCKET CLIENT client with id %q requested a streamsync", conn.Id())
-
-		c, err := h.clientHandler.GetClient(conn.Id())
-		if err != nil {
-			log.Printf("ERR SOCKET CLIENT unable to retrieve client from connection id. Ignoring request_streamsync request: %v", err)
-			return
-		}
-
-		roomName, exists := c.GetRoom()
-		if !exists {
-			log.Printf("ERR SOCKET CLIENT client with id (%q) has no room association. Ignoring streamsync request.", c.GetId())
-			return
-		}
-
-		sPlayback, exists := h.PlaybackHandler.GetStreamPlayback(roomName)
-		if !exists {
-
-		}
-
-		c.BroadcastTo("streamsync", &client.Response{
-			Id:    c.GetId(),
-			Extra: sPlayback.GetStatus(),
-		})
-	})
+	h.router.BindNoArgs(conn, "disconnection")
+	h.router.Bind(conn, "request_updateusername")
+	h.router.Bind(conn, "request_chatmessage")
+	h.router.Bind(conn, "request_streamsync")
 }
 
 // ParseCommandMessage receives a client pointer and a data map sent by a client
@@ -199,6 +104,10 @@ func (h *Handler) RegisterClient(sockioconn sockio.Socket) {
 	c := h.clientHandler.CreateClient(sockioconn)
 	c.JoinRoom(roomName)
 
+	if h.Permissions.EnsureOwner(roomName, c.UUID()) {
+		log.Printf("INFO SOCKET CLIENT client with id %q is the first to join room %q; granting room-owner permissions", c.UUID(), roomName)
+	}
+
 	c.BroadcastFrom("info_clientjoined", &client.Response{
 		Id: c.GetId(),
 	})
@@ -207,7 +116,60 @@ func (h *Handler) RegisterClient(sockioconn sockio.Socket) {
 	if !exists {
 		log.Printf("INFO SOCKET CLIENT StreamPlayback did not exist for room with name %q. Creating...", roomName)
 		sPlayback = h.PlaybackHandler.NewStreamPlayback(roomName)
+
+		// currentTrack/currentTrackStart are shared by the OnStreamChanged
+		// and OnTick callbacks below to back the scrobble.Manager calls: a
+		// track "starts" when the room's stream changes, and "elapsed" for
+		// MaybeScrobble is measured from that point. StreamPlayback may
+		// invoke these callbacks from different goroutines (a tick timer
+		// vs. a socket-event-triggered stream change), so access to both
+		// variables is guarded by trackMu.
+		var trackMu sync.Mutex
+		var currentTrack scrobble.Track
+		var currentTrackStart time.Time
+
+		sPlayback.OnStreamChanged(func(s *stream.Stream) {
+			track := scrobble.Track{}
+			hasTrack := false
+
+			if s != nil {
+				if src, ok := (*s).GetInfo().(scrobble.TrackSource); ok {
+					track = scrobble.TrackFromSource(src)
+					hasTrack = true
+				}
+			}
+
+			trackMu.Lock()
+			currentTrackStart = time.Now()
+			currentTrack = track
+			trackMu.Unlock()
+
+			if !hasTrack {
+				return
+			}
+
+			for _, roomClient := range h.clientHandler.GetClients() {
+				if room, exists := roomClient.GetRoom(); !exists || room != roomName {
+					continue
+				}
+				h.ScrobbleManager.NowPlaying(roomClient.UUID(), track)
+			}
+		})
+
 		sPlayback.OnTick(func(currentTime int) {
+			trackMu.Lock()
+			track := currentTrack
+			trackStart := currentTrackStart
+			trackMu.Unlock()
+
+			elapsed := time.Duration(currentTime) * time.Second
+			for _, roomClient := range h.clientHandler.GetClients() {
+				if room, exists := roomClient.GetRoom(); !exists || room != roomName {
+					continue
+				}
+				h.ScrobbleManager.MaybeScrobble(roomClient.UUID(), track, elapsed, trackStart)
+			}
+
 			if currentTime%ROOM_DEFAULT_STREAMSYNC_RATE != 0 {
 				return
 			}
@@ -231,10 +193,17 @@ func (h *Handler) RegisterClient(sockioconn sockio.Socket) {
 
 	pStream, exists := sPlayback.GetStream()
 	if exists {
+		// info already carries whatever variant URL was picked for this
+		// stream kind at enqueue time (e.g. HLSEndpoint picks the leading
+		// variant before the stream is ever set), so there is no
+		// kind-specific behavior left to apply when simply forwarding it.
+		info := (*pStream).GetInfo()
+
 		log.Printf("INFO SOCKET CLIENT found stream info (%s) associated with StreamPlayback for room with name %q... Sending \"streamload\" signal to client", (*pStream).GetStreamURL(), roomName)
+
 		c.BroadcastTo("streamload", &client.Response{
 			Id:    c.GetId(),
-			Extra: (*pStream).GetInfo(),
+			Extra: info,
 		})
 	}
 }
@@ -251,7 +220,7 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	h.server.ServeHTTP(w, r)
 }
 
-func NewHandler(commandHandler cmd.SocketCommandHandler, clientHandler client.SocketClientHandler, playbackHandler playback.StreamPlaybackHandler, streamHandler stream.StreamHandler) *Handler {
+func NewHandler(commandHandler cmd.SocketCommandHandler, clientHandler client.SocketClientHandler, playbackHandler playback.StreamPlaybackHandler, streamHandler stream.StreamHandler, scrobbleManager *scrobble.Manager) *Handler {
 	socketServer, err := NewServer(nil)
 	if err != nil {
 		log.Fatal(err)
@@ -262,10 +231,18 @@ func NewHandler(commandHandler cmd.SocketCommandHandler, clientHandler client.So
 		CommandHandler:  commandHandler,
 		PlaybackHandler: playbackHandler,
 		StreamHandler:   streamHandler,
+		Permissions:     permissions.NewRegistry(),
+		ScrobbleManager: scrobbleManager,
 
+		router: router.NewMessageRouter(clientHandler, playbackHandler),
 		server: socketServer,
 	}
 
+	handler.router.Use(router.RecoveryMiddleware())
+	handler.router.Use(router.LoggingMiddleware())
+	handler.router.Use(router.RateLimitMiddleware(ROOM_DEFAULT_COMMAND_RATE))
+
+	handler.addRouterHandlers()
 	handler.addRequestHandlers()
 	return handler
 }
@@ -0,0 +1,171 @@
+package socket
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/juanvallejo/streaming-server/pkg/permissions"
+	"github.com/juanvallejo/streaming-server/pkg/socket/client"
+	"github.com/juanvallejo/streaming-server/pkg/socket/router"
+	"github.com/juanvallejo/streaming-server/pkg/socket/util"
+)
+
+// commandPermissions maps a chat command id to the permission bit
+// required to invoke it, beyond the baseline PermChat required to issue
+// any chat command at all. Commands not listed here require only PermChat.
+var commandPermissions = map[string]permissions.Permission{
+	"skip":    permissions.PermSkip,
+	"grant":   permissions.PermStreamControl,
+	"revoke":  permissions.PermStreamControl,
+	"promote": permissions.PermStreamControl,
+	"kick":    permissions.PermKick,
+}
+
+// addRouterHandlers registers the reference HandlerFunc implementation for
+// each event this server understands. New events should be added here
+// rather than as ad-hoc conn.On(...) calls in HandleClientConnection.
+func (h *Handler) addRouterHandlers() {
+	h.router.Handle("disconnection", h.disconnectionHandler())
+	h.router.Handle("request_updateusername", h.updateUsernameHandler())
+	h.router.Handle("request_chatmessage", router.PermissionMiddleware(h.requirePermission(permissions.PermChat))(h.chatMessageHandler()))
+	h.router.Handle("request_streamsync", h.streamSyncHandler())
+}
+
+// requirePermission returns a router.PermissionFunc rejecting events from
+// a client that does not hold required for its room.
+func (h *Handler) requirePermission(required permissions.Permission) router.PermissionFunc {
+	return func(ctx *router.Context) bool {
+		return ctx.Client != nil && util.RequirePermission(h.Permissions, ctx.Room, ctx.Client.UUID(), required) == nil
+	}
+}
+
+// disconnectionHandler tears down client/room bookkeeping for a client
+// that has dropped its connection.
+func (h *Handler) disconnectionHandler() router.HandlerFunc {
+	return func(ctx *router.Context) error {
+		log.Printf("INFO DCONN SOCKET client with id %q has disconnected\n", ctx.Conn.Id())
+
+		if ctx.Client != nil {
+			userName, exists := ctx.Client.GetUsername()
+			if exists {
+				ctx.Client.BroadcastFrom("info_clientleft", &client.Response{
+					Id:   ctx.Conn.Id(),
+					From: userName,
+				})
+			}
+		}
+
+		return h.DeregisterClient(ctx.Conn)
+	}
+}
+
+// updateUsernameHandler applies a client-requested username change.
+func (h *Handler) updateUsernameHandler() router.HandlerFunc {
+	return func(ctx *router.Context) error {
+		data, ok := ctx.Data.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("error: malformed request_updateusername payload")
+		}
+
+		username, ok := data["user"].(string)
+		if !ok {
+			return fmt.Errorf("error: client sent malformed request to update username")
+		}
+
+		if ctx.Client == nil {
+			return fmt.Errorf("error: unable to retrieve client for request_updateusername")
+		}
+
+		err := util.UpdateClientUsername(ctx.Client, username, h.clientHandler, h.PlaybackHandler)
+		if err != nil {
+			ctx.Client.BroadcastErrorTo(err)
+			return err
+		}
+
+		return nil
+	}
+}
+
+// chatMessageHandler either interprets a chat message as a "/command" and
+// hands it off to the CommandHandler, or broadcasts it as a plain message.
+// The baseline PermChat check for this event is enforced by
+// router.PermissionMiddleware in addRouterHandlers, ahead of this handler.
+func (h *Handler) chatMessageHandler() router.HandlerFunc {
+	return func(ctx *router.Context) error {
+		data, ok := ctx.Data.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("error: malformed request_chatmessage payload")
+		}
+
+		if ctx.Client == nil {
+			return fmt.Errorf("error: unable to retrieve client for request_chatmessage")
+		}
+		c := ctx.Client
+
+		err, command, isCommand := h.ParseCommandMessage(c, data)
+		if err != nil {
+			c.BroadcastSystemMessageTo(err.Error())
+			return err
+		}
+
+		if isCommand {
+			cmdSegments := strings.Split(command, " ")
+			cmdArgs := []string{}
+			if len(cmdSegments) > 1 {
+				cmdArgs = cmdSegments[1:]
+			}
+
+			if required, ok := commandPermissions[cmdSegments[0]]; ok {
+				if err := util.RequirePermission(h.Permissions, ctx.Room, c.UUID(), required); err != nil {
+					c.BroadcastSystemMessageTo(err.Error())
+					return err
+				}
+			}
+
+			log.Printf("INFO SOCKET CLIENT interpreting chat message as user command %q for client id (%q)", command, c.GetId())
+			result, err := h.CommandHandler.ExecuteCommand(cmdSegments[0], cmdArgs, c, h.clientHandler, h.PlaybackHandler, h.StreamHandler)
+			if err != nil {
+				c.BroadcastSystemMessageTo(err.Error())
+				return err
+			}
+
+			if len(result) > 0 {
+				c.BroadcastSystemMessageTo(result)
+			}
+			return nil
+		}
+
+		// TODO: parse message multimedia
+		// if err := h.ReplaceMessageImageURL(data); err != nil {
+		// 	log.Printf("SOCKET CLIENT WARN ")
+		// }
+
+		res := client.ResponseFromClientData(data)
+		c.BroadcastAll("chatmessage", &res)
+
+		log.Printf("INFO SOCKET CLIENT chatmessage received %v\n", data)
+		return nil
+	}
+}
+
+// streamSyncHandler replies to a client's playback-position sync request
+// with the room's current StreamPlayback status.
+func (h *Handler) streamSyncHandler() router.HandlerFunc {
+	return func(ctx *router.Context) error {
+		if ctx.Client == nil {
+			return fmt.Errorf("error: unable to retrieve client for request_streamsync")
+		}
+
+		if ctx.Playback == nil {
+			return fmt.Errorf("error: client with id (%q) has no room association. Ignoring streamsync request", ctx.Client.GetId())
+		}
+
+		ctx.Client.BroadcastTo("streamsync", &client.Response{
+			Id:    ctx.Client.GetId(),
+			Extra: ctx.Playback.GetStatus(),
+		})
+
+		return nil
+	}
+}
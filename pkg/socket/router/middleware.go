@@ -0,0 +1,82 @@
+package router
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// LoggingMiddleware logs every dispatched event along with the client
+// id it was dispatched for.
+func LoggingMiddleware() Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx *Context) error {
+			log.Printf("INFO ROUTER dispatching event %q for conn id %q", ctx.Event, ctx.Conn.Id())
+			return next(ctx)
+		}
+	}
+}
+
+// RecoveryMiddleware recovers a panicking handler and reports it as a
+// regular error so a single bad event cannot take down the connection's
+// goroutine.
+func RecoveryMiddleware() Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx *Context) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					err = fmt.Errorf("error: handler for event %q panicked: %v", ctx.Event, r)
+				}
+			}()
+
+			return next(ctx)
+		}
+	}
+}
+
+// PermissionFunc reports whether ctx's client is allowed to invoke event.
+type PermissionFunc func(ctx *Context) bool
+
+// PermissionMiddleware rejects events whose client fails the given
+// PermissionFunc check.
+func PermissionMiddleware(allowed PermissionFunc) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx *Context) error {
+			if !allowed(ctx) {
+				return fmt.Errorf("error: client is not permitted to perform event %q", ctx.Event)
+			}
+
+			return next(ctx)
+		}
+	}
+}
+
+// RateLimitMiddleware rejects events from a given client id more
+// frequent than once per interval, per event name.
+func RateLimitMiddleware(interval time.Duration) Middleware {
+	var mu sync.Mutex
+	last := map[string]time.Time{}
+
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx *Context) error {
+			if ctx.Client == nil {
+				return next(ctx)
+			}
+
+			key := ctx.Client.UUID() + ":" + ctx.Event
+
+			mu.Lock()
+			prev, seen := last[key]
+			now := time.Now()
+			if seen && now.Sub(prev) < interval {
+				mu.Unlock()
+				return fmt.Errorf("error: rate limit exceeded for event %q", ctx.Event)
+			}
+			last[key] = now
+			mu.Unlock()
+
+			return next(ctx)
+		}
+	}
+}
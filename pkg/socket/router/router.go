@@ -0,0 +1,150 @@
+// Package router replaces the hard-coded conn.On("request_...", ...)
+// blocks previously inlined in socket.Handler with a versioned
+// command/message router: handlers register against an event name and
+// receive a pre-resolved Context instead of repeating client/room/
+// playback lookup boilerplate themselves.
+package router
+
+import (
+	"log"
+
+	sockio "github.com/googollee/go-socket.io"
+
+	"github.com/juanvallejo/streaming-server/pkg/playback"
+	"github.com/juanvallejo/streaming-server/pkg/socket/client"
+)
+
+// DefaultNamespace is used when a handler is registered without an
+// explicit "v1."/"v2." protocol-version prefix.
+const DefaultNamespace = "v1"
+
+// Context carries everything a HandlerFunc needs to act on a single
+// incoming socket event.
+type Context struct {
+	Event    string
+	Conn     sockio.Socket
+	Client   *client.Client
+	Room     string
+	Playback playback.StreamPlayback
+	Data     interface{}
+}
+
+// HandlerFunc handles a single routed socket event.
+type HandlerFunc func(ctx *Context) error
+
+// Middleware wraps a HandlerFunc, e.g. to add logging, panic-recovery,
+// permission checks, or rate-limiting ahead of the real handler.
+type Middleware func(HandlerFunc) HandlerFunc
+
+// MessageRouter dispatches incoming socket events to registered
+// HandlerFuncs, resolving a Context and running middleware ahead of them.
+type MessageRouter struct {
+	handlers   map[string]HandlerFunc
+	middleware []Middleware
+
+	clientHandler   client.SocketClientHandler
+	playbackHandler playback.StreamPlaybackHandler
+}
+
+func NewMessageRouter(clientHandler client.SocketClientHandler, playbackHandler playback.StreamPlaybackHandler) *MessageRouter {
+	return &MessageRouter{
+		handlers:        map[string]HandlerFunc{},
+		clientHandler:   clientHandler,
+		playbackHandler: playbackHandler,
+	}
+}
+
+// Use registers middleware to run, in order, ahead of every handler.
+func (r *MessageRouter) Use(mw Middleware) {
+	r.middleware = append(r.middleware, mw)
+}
+
+// Handle registers fn for event. event may carry a "v1."/"v2."
+// protocol-version prefix (e.g. "v2.request_chatmessage"); unprefixed
+// events register under DefaultNamespace, so existing handlers and
+// future protocol revisions can coexist on the same router.
+func (r *MessageRouter) Handle(event string, fn HandlerFunc) {
+	r.handlers[namespacedKey(event)] = fn
+}
+
+// Bind attaches the router as conn's handler for event, dispatching
+// through the registered middleware chain on every message.
+func (r *MessageRouter) Bind(conn sockio.Socket, event string) {
+	conn.On(event, func(data interface{}) {
+		r.Dispatch(conn, event, data)
+	})
+}
+
+// BindNoArgs is like Bind, for events (e.g. "disconnection") emitted
+// without a data payload.
+func (r *MessageRouter) BindNoArgs(conn sockio.Socket, event string) {
+	conn.On(event, func() {
+		r.Dispatch(conn, event, nil)
+	})
+}
+
+// Dispatch resolves a Context for conn/event/data and runs the matching
+// handler (if any) through the registered middleware chain.
+func (r *MessageRouter) Dispatch(conn sockio.Socket, event string, data interface{}) {
+	fn, ok := r.handlers[namespacedKey(event)]
+	if !ok {
+		log.Printf("WARN ROUTER no handler registered for event %q", event)
+		return
+	}
+
+	for i := len(r.middleware) - 1; i >= 0; i-- {
+		fn = r.middleware[i](fn)
+	}
+
+	ctx, err := r.buildContext(conn, event, data)
+	if err != nil {
+		// buildContext still returns a partially-populated ctx (Event/
+		// Conn/Data, with Client left nil) on this error. Dispatch the
+		// event anyway rather than dropping it: a disconnection, in
+		// particular, must still reach disconnectionHandler so it can run
+		// h.DeregisterClient even when the client lookup that backs
+		// ctx.Client failed. Handlers are expected to treat a nil
+		// ctx.Client as "no authenticated client for this event" rather
+		// than assume lookup always succeeds.
+		log.Printf("WARN ROUTER unable to resolve client for event %q: %v; dispatching with a nil Client", event, err)
+	}
+
+	if err := fn(ctx); err != nil {
+		log.Printf("ERR ROUTER handler for event %q returned error: %v", event, err)
+	}
+}
+
+func (r *MessageRouter) buildContext(conn sockio.Socket, event string, data interface{}) (*Context, error) {
+	ctx := &Context{
+		Event: event,
+		Conn:  conn,
+		Data:  data,
+	}
+
+	c, err := r.clientHandler.GetClient(conn.Id())
+	if err != nil {
+		return ctx, err
+	}
+	ctx.Client = c
+
+	room, exists := c.GetRoom()
+	if !exists {
+		return ctx, nil
+	}
+	ctx.Room = room
+
+	if sPlayback, exists := r.playbackHandler.GetStreamPlayback(room); exists {
+		ctx.Playback = sPlayback
+	}
+
+	return ctx, nil
+}
+
+// namespacedKey normalizes event into its fully-namespaced registration
+// key, defaulting to DefaultNamespace when event carries no "vN." prefix.
+func namespacedKey(event string) string {
+	if len(event) > 3 && event[2] == '.' && event[0] == 'v' && event[1] >= '0' && event[1] <= '9' {
+		return event
+	}
+	return DefaultNamespace + "." + event
+}
@@ -0,0 +1,19 @@
+package util
+
+import (
+	"fmt"
+
+	"github.com/juanvallejo/streaming-server/pkg/permissions"
+)
+
+// RequirePermission returns an error if clientId does not currently hold
+// every bit in required for room. It is the single implementation shared
+// by router.PermissionMiddleware and command handlers that need a
+// permission check finer-grained than an entire routed event (e.g. a
+// specific chat subcommand), so the two call sites never drift apart.
+func RequirePermission(registry *permissions.Registry, room, clientId string, required permissions.Permission) error {
+	if !registry.Has(room, clientId, required) {
+		return fmt.Errorf("error: you do not have permission to perform this action")
+	}
+	return nil
+}
@@ -0,0 +1,5 @@
+package stream
+
+// StreamKindHLS identifies an HLS adaptive-bitrate stream, as opposed to
+// a direct-file SoundCloud/YouTube stream.
+const StreamKindHLS = "stream#hls"
@@ -0,0 +1,49 @@
+package sync
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+const storeFileName = "sync_state.json"
+
+// store persists a Manager's playlist/item state as JSON under a data
+// root directory, so tracked playlists survive a server restart.
+type store struct {
+	path string
+}
+
+func newStore(dataRoot string) *store {
+	return &store{path: filepath.Join(dataRoot, storeFileName)}
+}
+
+// load reads the store's JSON file into dest. A missing file is not an
+// error; dest is left untouched so Manager starts with an empty state.
+func (s *store) load(dest interface{}) error {
+	data, err := ioutil.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, dest)
+}
+
+// save writes src to the store's JSON file, creating its parent
+// directory if necessary.
+func (s *store) save(src interface{}) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(src, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(s.path, data, 0644)
+}
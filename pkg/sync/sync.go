@@ -0,0 +1,325 @@
+// Package sync implements a resumable, backoff-driven manager for
+// tracking long-lived external playlists (SoundCloud/YouTube) registered
+// to a room, modeled on the ytsync polling pattern: each tracked item
+// moves through a pending -> queued -> syncing -> synced/failed status
+// lifecycle and is persisted to an on-disk JSON store so progress
+// survives a server restart.
+package sync
+
+import (
+	"context"
+	"log"
+	"math"
+	"sync"
+	"time"
+)
+
+// Status is the lifecycle state of a single tracked playlist item.
+type Status string
+
+const (
+	StatusPending Status = "pending"
+	StatusQueued  Status = "queued"
+	StatusSyncing Status = "syncing"
+	StatusSynced  Status = "synced"
+	StatusFailed  Status = "failed"
+)
+
+const (
+	// defaultBackoffBase is the initial delay applied after the first
+	// failed poll attempt; subsequent attempts double it.
+	defaultBackoffBase = 5 * time.Second
+	defaultBackoffMax  = 10 * time.Minute
+)
+
+// Item tracks the sync state of a single item discovered within a
+// registered playlist.
+type Item struct {
+	URL         string    `json:"url"`
+	Status      Status    `json:"status"`
+	Tries       int       `json:"tries"`
+	LastError   string    `json:"lastError,omitempty"`
+	NextAttempt time.Time `json:"nextAttempt"`
+}
+
+// Playlist is a single external playlist registered to a room's queue.
+type Playlist struct {
+	URL         string           `json:"url"`
+	Source      string           `json:"source"`
+	Items       map[string]*Item `json:"items"`
+	NextAttempt time.Time        `json:"nextAttempt"`
+	Tries       int              `json:"tries"`
+}
+
+// Fetcher polls a registered playlist URL and returns the item URLs it
+// currently contains. Callers register one Fetcher per external source
+// (e.g. "soundcloud", "youtube"), typically wrapping the existing
+// handleSoundCloudApiRequest/handleApiSearch HTTP request paths. ctx is
+// bound to Manager's own lifetime (canceled on Stop), not to any single
+// HTTP request.
+type Fetcher func(ctx context.Context, playlistURL string) ([]string, error)
+
+// EnqueueFunc forwards a newly-discovered item URL into the room's
+// StreamHandler queue.
+type EnqueueFunc func(room, url string) error
+
+// Config holds the knobs controlling Manager's polling behavior.
+type Config struct {
+	// MaxTries is the number of consecutive failed poll attempts after
+	// which a playlist is no longer retried.
+	MaxTries int
+	// ConcurrentJobs bounds how many playlists are polled at once.
+	ConcurrentJobs int
+	// StopOnError, if true, stops polling a playlist entirely on its
+	// first error rather than backing off and retrying.
+	StopOnError bool
+	// PollInterval is how often Manager checks for playlists due a poll.
+	PollInterval time.Duration
+	// DataRoot is the directory under the server data root that the
+	// on-disk JSON store is written to.
+	DataRoot string
+}
+
+// Manager polls registered playlists on an interval, tracking per-item
+// status and re-enqueuing newly-available items into StreamHandler.
+type Manager struct {
+	mu sync.Mutex
+
+	cfg      Config
+	fetchers map[string]Fetcher
+	enqueue  EnqueueFunc
+
+	playlists map[string]map[string]*Playlist // room -> playlist url -> Playlist
+
+	store  *store
+	stopCh chan struct{}
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NewManager returns a Manager that persists its state under
+// cfg.DataRoot and forwards newly-available items via enqueue.
+func NewManager(cfg Config, enqueue EnqueueFunc) *Manager {
+	if cfg.MaxTries <= 0 {
+		cfg.MaxTries = 5
+	}
+	if cfg.ConcurrentJobs <= 0 {
+		cfg.ConcurrentJobs = 4
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = 30 * time.Second
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	m := &Manager{
+		cfg:       cfg,
+		fetchers:  map[string]Fetcher{},
+		enqueue:   enqueue,
+		playlists: map[string]map[string]*Playlist{},
+		store:     newStore(cfg.DataRoot),
+		stopCh:    make(chan struct{}),
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+
+	if err := m.store.load(&m.playlists); err != nil {
+		log.Printf("WARN SYNC unable to load sync store, starting empty: %v", err)
+	}
+
+	return m
+}
+
+// RegisterFetcher associates a Fetcher with an external source id
+// (e.g. "soundcloud", "youtube").
+func (m *Manager) RegisterFetcher(source string, fn Fetcher) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.fetchers[source] = fn
+}
+
+// Register adds playlistURL to room's tracked playlists under the given
+// source, if it is not already tracked.
+func (m *Manager) Register(room, source, playlistURL string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.playlists[room]; !ok {
+		m.playlists[room] = map[string]*Playlist{}
+	}
+
+	if _, exists := m.playlists[room][playlistURL]; exists {
+		return nil
+	}
+
+	m.playlists[room][playlistURL] = &Playlist{
+		URL:    playlistURL,
+		Source: source,
+		Items:  map[string]*Item{},
+	}
+
+	return m.store.save(m.playlists)
+}
+
+// StatusCounts returns the number of tracked items in room across each
+// Status, used by the "/sync status" chat command.
+func (m *Manager) StatusCounts(room string) map[Status]int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	counts := map[Status]int{}
+	for _, playlist := range m.playlists[room] {
+		for _, item := range playlist.Items {
+			counts[item.Status]++
+		}
+	}
+
+	return counts
+}
+
+// Start begins the polling loop, checking every PollInterval for
+// playlists whose NextAttempt has elapsed. It blocks until Stop is called.
+func (m *Manager) Start() {
+	ticker := time.NewTicker(m.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			m.pollDue()
+		case <-m.stopCh:
+			return
+		}
+	}
+}
+
+// Stop ends the polling loop started by Start and cancels the context
+// passed to any Fetcher call still in flight.
+func (m *Manager) Stop() {
+	m.cancel()
+	close(m.stopCh)
+}
+
+func (m *Manager) pollDue() {
+	type job struct {
+		room     string
+		playlist *Playlist
+	}
+
+	now := time.Now()
+	jobs := []job{}
+
+	m.mu.Lock()
+	for room, playlists := range m.playlists {
+		for _, playlist := range playlists {
+			if playlist.NextAttempt.After(now) {
+				continue
+			}
+			if playlist.Tries >= m.cfg.MaxTries || (m.cfg.StopOnError && playlist.Tries > 0) {
+				continue
+			}
+			jobs = append(jobs, job{room: room, playlist: playlist})
+		}
+	}
+	m.mu.Unlock()
+
+	sem := make(chan struct{}, m.cfg.ConcurrentJobs)
+	var wg sync.WaitGroup
+
+	for _, j := range jobs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(j job) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			m.pollPlaylist(j.room, j.playlist)
+		}(j)
+	}
+
+	wg.Wait()
+}
+
+// pollPlaylist fetches the current item list for playlist and
+// re-enqueues any items that have neither synced nor failed, backing off exponentially on
+// repeated failures. Each item visibly passes through StatusSyncing
+// while its enqueue call is in flight: the lock is released for that
+// call so a concurrent StatusCounts call can observe it, rather than
+// being held for the whole poll.
+func (m *Manager) pollPlaylist(room string, playlist *Playlist) {
+	m.mu.Lock()
+	fetch, ok := m.fetchers[playlist.Source]
+	m.mu.Unlock()
+
+	if !ok {
+		log.Printf("ERR SYNC no fetcher registered for source %q, skipping playlist %q", playlist.Source, playlist.URL)
+		return
+	}
+
+	urls, err := fetch(m.ctx, playlist.URL)
+
+	m.mu.Lock()
+	if err != nil {
+		playlist.Tries++
+		log.Printf("ERR SYNC polling playlist %q failed (attempt %d): %v", playlist.URL, playlist.Tries, err)
+
+		if m.cfg.StopOnError || playlist.Tries >= m.cfg.MaxTries {
+			playlist.NextAttempt = time.Now().Add(defaultBackoffMax)
+		} else {
+			playlist.NextAttempt = time.Now().Add(backoffDelay(playlist.Tries))
+		}
+
+		m.store.save(m.playlists)
+		m.mu.Unlock()
+		return
+	}
+
+	playlist.Tries = 0
+	playlist.NextAttempt = time.Now().Add(m.cfg.PollInterval)
+
+	pending := []*Item{}
+	for _, url := range urls {
+		item, exists := playlist.Items[url]
+		if !exists {
+			item = &Item{URL: url, Status: StatusPending}
+			playlist.Items[url] = item
+		}
+
+		if item.Status == StatusSynced || item.Status == StatusFailed {
+			continue
+		}
+
+		item.Status = StatusQueued
+		pending = append(pending, item)
+	}
+	m.store.save(m.playlists)
+	m.mu.Unlock()
+
+	for _, item := range pending {
+		m.mu.Lock()
+		item.Status = StatusSyncing
+		m.mu.Unlock()
+
+		enqueueErr := m.enqueue(room, item.URL)
+
+		m.mu.Lock()
+		if enqueueErr != nil {
+			item.Status = StatusFailed
+			item.LastError = enqueueErr.Error()
+		} else {
+			item.Status = StatusSynced
+		}
+		m.store.save(m.playlists)
+		m.mu.Unlock()
+	}
+}
+
+// backoffDelay returns the exponential backoff delay for the given
+// (1-indexed) attempt number, capped at defaultBackoffMax.
+func backoffDelay(attempt int) time.Duration {
+	delay := time.Duration(float64(defaultBackoffBase) * math.Pow(2, float64(attempt-1)))
+	if delay > defaultBackoffMax {
+		return defaultBackoffMax
+	}
+	return delay
+}
@@ -0,0 +1,29 @@
+package sync
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackoffDelay(t *testing.T) {
+	cases := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, defaultBackoffBase},
+		{2, defaultBackoffBase * 2},
+		{3, defaultBackoffBase * 4},
+	}
+
+	for _, c := range cases {
+		if got := backoffDelay(c.attempt); got != c.want {
+			t.Errorf("backoffDelay(%d) = %v, want %v", c.attempt, got, c.want)
+		}
+	}
+}
+
+func TestBackoffDelayCapsAtMax(t *testing.T) {
+	if got := backoffDelay(20); got != defaultBackoffMax {
+		t.Errorf("expected backoffDelay to cap at %v for a large attempt count, got %v", defaultBackoffMax, got)
+	}
+}